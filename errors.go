@@ -0,0 +1,173 @@
+package lg
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the number of frames captured for a stack trace.
+const maxStackDepth = 64
+
+// ErrorFormat is how error values are encoded.
+type ErrorFormat uint8
+
+const (
+	// ErrorMessage encodes errors as their message.
+	ErrorMessage ErrorFormat = iota
+	// ErrorChain encodes the errors wrapping others or having a stack trace
+	// as an object: their message under "msg", the messages of the errors
+	// they wrap under "causes", and the stack trace of the innermost error
+	// having one under "stack". The other errors are encoded as their message.
+	//
+	// Errors wrap others with an Unwrap() error or Unwrap() []error method,
+	// and have a stack trace with a StackTrace method returning program
+	// counters, like the errors of WithStack or github.com/pkg/errors.
+	ErrorChain
+)
+
+// WithStack returns err annotated with the stack of its caller, see
+// ErrorChain. It returns nil when err is nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	return &stackError{err: err, pcs: pcs[:n]}
+}
+
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// StackTrace returns the program counters of the stack of the error.
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+// encodeError encodes err according to the error format of the logger.
+func (l *Logger) encodeError(err error) any {
+	if l.errorFormat != ErrorChain {
+		return err.Error()
+	}
+	var causes []any
+	var stack []runtime.Frame
+	var walk func(err error, depth int)
+	walk = func(err error, depth int) {
+		if frames := stackTrace(err); len(frames) > 0 {
+			stack = frames
+		}
+		if depth >= maxValueDepth {
+			return
+		}
+		var wrapped []error
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			wrapped = u.Unwrap()
+		case interface{ Unwrap() error }:
+			wrapped = []error{u.Unwrap()}
+		}
+		for _, cause := range wrapped {
+			if cause == nil {
+				continue
+			}
+			// skip the causes only adding a stack trace
+			if msg := cause.Error(); msg != err.Error() {
+				causes = append(causes, msg)
+			}
+			walk(cause, depth+1)
+		}
+	}
+	walk(err, 0)
+
+	if len(causes) == 0 && len(stack) == 0 {
+		return err.Error()
+	}
+	fields := []Field{{"msg", err.Error()}}
+	if len(causes) > 0 {
+		fields = append(fields, Field{"causes", causes})
+	}
+	if len(stack) > 0 {
+		fields = append(fields, Field{"stack", formatStack(stack)})
+	}
+	return fields
+}
+
+var frameType = reflect.TypeOf(runtime.Frame{})
+
+// stackTrace returns the frames of the stack trace of err, if it has one.
+func stackTrace(err error) []runtime.Frame {
+	if err, ok := err.(interface{ StackTrace() []uintptr }); ok {
+		return callersFrames(err.StackTrace())
+	}
+	// the stack traces of other packages have their own types
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	out := m.Call(nil)[0]
+	if out.Kind() != reflect.Slice {
+		return nil
+	}
+	switch {
+	case out.Type().Elem().Kind() == reflect.Uintptr:
+		pcs := make([]uintptr, out.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(out.Index(i).Uint())
+		}
+		return callersFrames(pcs)
+	case out.Type().Elem() == frameType:
+		frames := make([]runtime.Frame, out.Len())
+		for i := range frames {
+			frames[i] = out.Index(i).Interface().(runtime.Frame)
+		}
+		return frames
+	}
+	return nil
+}
+
+func callersFrames(pcs []uintptr) []runtime.Frame {
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		if f.Function != "" || f.File != "" {
+			frames = append(frames, f)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// formatStack renders frames like the stack traces of panics.
+func formatStack(frames []runtime.Frame) string {
+	var b strings.Builder
+	for i, f := range frames {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line))
+	}
+	return b.String()
+}
+
+// lgPackage is the import path of this package, to drop its frames from
+// the stacks of the logging goroutine.
+var lgPackage = reflect.TypeOf(Logger{}).PkgPath()
+
+// callerStack returns the stack of the goroutine, from the caller of the
+// package functions.
+func callerStack() string {
+	pcs := make([]uintptr, maxStackDepth)
+	frames := callersFrames(pcs[:runtime.Callers(1, pcs)])
+	for len(frames) > 0 && strings.HasPrefix(frames[0].Function, lgPackage+".") {
+		frames = frames[1:]
+	}
+	return formatStack(frames)
+}
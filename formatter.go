@@ -9,8 +9,51 @@ const (
 	TextFormatter Formatter = iota
 	// JSONFormatter is a formatter that formats log messages as JSON.
 	JSONFormatter
+	// SyslogFormatter is a formatter that formats log messages as syslog messages,
+	// see SyslogOptions and NewSyslogWriter.
+	SyslogFormatter
+	// JournalFormatter is a formatter that formats log messages as journal
+	// entries, in the native protocol of systemd-journald, see JournalOptions
+	// and NewJournalWriter.
+	JournalFormatter
+	// GELFFormatter is a formatter that formats log messages as GELF 1.1
+	// messages, for Graylog, see GELFOptions and NewGELFWriter.
+	GELFFormatter
 )
 
+// JSONProfile is the schema of the JSON formatter, the keys and values of
+// the time, level, message and caller of the records.
+type JSONProfile uint8
+
+const (
+	// JSONProfileDefault uses TimestampKey, LevelKey, MessageKey, CallerKey
+	// and PrefixKey.
+	JSONProfileDefault JSONProfile = iota
+	// JSONProfileECS follows the Elastic Common Schema: @timestamp, log.level,
+	// message, log.logger for the prefix and log.origin for the caller.
+	JSONProfileECS
+	// JSONProfileGCP follows the structured logging of Google Cloud Logging:
+	// time, severity, message and logging.googleapis.com/sourceLocation for
+	// the caller. The value of a "trace" field, like
+	// "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", is moved
+	// to logging.googleapis.com/trace.
+	JSONProfileGCP
+)
+
+// ANSIPolicy is how the text formatter handles escape sequences
+// found in user-supplied text.
+type ANSIPolicy uint8
+
+const (
+	// ANSIEscape escapes the sequences, so they show up as text.
+	ANSIEscape ANSIPolicy = iota
+	// ANSIStrip removes the sequences.
+	ANSIStrip
+)
+
+// The default keys of the built-in fields, for the loggers whose Keys
+// leave them empty. They are read when a logger is created or its keys are
+// set, prefer Options.Keys.
 var (
 	// TimestampKey is the key for the timestamp.
 	TimestampKey = "time"
@@ -23,3 +66,33 @@ var (
 	// PrefixKey is the key for the prefix.
 	PrefixKey = "prefix"
 )
+
+// Keys are the keys of the built-in fields of a logger. An empty key is
+// the package default, like TimestampKey.
+type Keys struct {
+	Timestamp string
+	Message   string
+	Level     string
+	Caller    string
+	Prefix    string
+}
+
+// withDefaults returns k with its empty keys set to the package defaults.
+func (k Keys) withDefaults() Keys {
+	if k.Timestamp == "" {
+		k.Timestamp = TimestampKey
+	}
+	if k.Message == "" {
+		k.Message = MessageKey
+	}
+	if k.Level == "" {
+		k.Level = LevelKey
+	}
+	if k.Caller == "" {
+		k.Caller = CallerKey
+	}
+	if k.Prefix == "" {
+		k.Prefix = PrefixKey
+	}
+	return k
+}
@@ -0,0 +1,282 @@
+package lg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGELFChunkSize = 1420
+	gelfMaxChunks        = 128
+	gelfChunkHeader      = 12
+)
+
+// GELFOptions configures GELFFormatter.
+type GELFOptions struct {
+	// Host is the host of the messages. The default is os.Hostname.
+	Host string
+}
+
+// withDefaults returns opts with its unset fields set to their defaults.
+func (opts GELFOptions) withDefaults() GELFOptions {
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	return opts
+}
+
+// gelfFormatter writes r as a GELF 1.1 message. The message is the
+// short_message, or the full_message with its first line as short_message
+// when it has several lines. The caller, prefix and fields are additional
+// fields, their names prefixed with an underscore.
+func (l *Logger) gelfFormatter(r *Record) {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	ms := t.UnixMilli()
+	ts := strconv.FormatInt(ms/1000, 10) + "." + fmt.Sprintf("%03d", ms%1000)
+
+	short, full := r.Message, ""
+	if i := strings.IndexByte(short, '\n'); i >= 0 {
+		short, full = short[:i], short
+	}
+	if short == "" {
+		// short_message is required
+		short = "-"
+	}
+
+	fields := make([]Field, 0, len(r.Fields)+9)
+	fields = append(fields,
+		Field{"version", "1.1"},
+		Field{"host", l.gelf.Host},
+		Field{"short_message", short},
+	)
+	if full != "" {
+		fields = append(fields, Field{"full_message", full})
+	}
+	fields = append(fields,
+		Field{"timestamp", json.RawMessage(ts)},
+		Field{"level", int64(syslogSeverity(r.Level))},
+	)
+	if r.file != "" {
+		fields = append(fields,
+			Field{"_file", r.file},
+			Field{"_line", int64(r.line)},
+			Field{"_function", r.function},
+		)
+	}
+	if r.Prefix != "" {
+		fields = append(fields, Field{gelfName(l.keys.Prefix), r.Prefix})
+	}
+	for _, f := range r.Fields {
+		fields = append(fields, Field{gelfName(f.Key), gelfValue(f.Value)})
+	}
+	fields = dedupFields(fields, l.duplicateKeys)
+
+	e := jsonEncoder{b: &l.b}
+	e.writeObject(fields)
+	l.b.WriteByte('\n')
+}
+
+// gelfName returns key as an additional field name: an underscore followed
+// by letters, digits, underscores, dashes and dots. "_id" is reserved.
+func gelfName(key string) string {
+	b := make([]byte, 0, len(key)+1)
+	b = append(b, '_')
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '_', c == '-', c == '.':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	if name := string(b); name != "_id" {
+		return name
+	}
+	return "_id_"
+}
+
+// gelfValue returns the encoded value v as a number or a string, the only
+// values of additional fields. Nested values are rendered in JSON.
+func gelfValue(v any) any {
+	switch v := v.(type) {
+	case int64, uint64, float64, string:
+		return v
+	case []any, []Field:
+		var b bytes.Buffer
+		e := jsonEncoder{b: &b}
+		e.writeValue(v)
+		return b.String()
+	}
+	return textValue(v)
+}
+
+// GELFCompression is the compression of the GELF messages sent over UDP.
+type GELFCompression uint8
+
+const (
+	// GELFGzip compresses the messages with gzip.
+	GELFGzip GELFCompression = iota
+	// GELFZlib compresses the messages with zlib.
+	GELFZlib
+	// GELFNone does not compress the messages.
+	GELFNone
+)
+
+// GELFWriterOptions configures a GELFWriter.
+type GELFWriterOptions struct {
+	// Compression is the compression of the messages sent over UDP, messages
+	// sent over TCP are not compressed. The default is GELFGzip.
+	Compression GELFCompression
+	// ChunkSize is the maximum size of the datagrams sent over UDP, larger
+	// messages are chunked. The default is 1420.
+	ChunkSize int
+}
+
+// GELFWriter writes the messages of GELFFormatter to a GELF input, like
+// Graylog, one message per Write. Like SyslogWriter, it reconnects when a
+// write fails.
+type GELFWriter struct {
+	network string
+	addr    string
+	opts    GELFWriterOptions
+
+	mu   sync.Mutex
+	conn redialer
+	buf  bytes.Buffer
+}
+
+// NewGELFWriter returns a writer to the GELF input listening on addr. The
+// network is "udp", where messages are compressed and chunked, or "tcp",
+// where messages are delimited by a null byte.
+func NewGELFWriter(network, addr string, opts ...GELFWriterOptions) (*GELFWriter, error) {
+	w := &GELFWriter{network: network, addr: addr}
+	if len(opts) > 0 {
+		w.opts = opts[0]
+	}
+	if w.opts.ChunkSize <= gelfChunkHeader {
+		w.opts.ChunkSize = defaultGELFChunkSize
+	}
+	w.conn.dial = w.dial
+	if err := w.conn.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *GELFWriter) dial(d *net.Dialer) (net.Conn, error) {
+	conn, err := d.Dial(w.network, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("gelf: %w", err)
+	}
+	return conn, nil
+}
+
+// Write sends p as a message, its trailing newline removed.
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	send := w.sendChunks
+	if !strings.HasPrefix(w.network, "udp") {
+		w.buf.Reset()
+		w.buf.Write(msg)
+		w.buf.WriteByte(0)
+		send = w.sendStream
+	} else if err := w.compress(msg); err != nil {
+		return 0, err
+	} else if w.chunks() > gelfMaxChunks {
+		return 0, errGELFTooLarge
+	}
+	if err := w.conn.write(send); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+var errGELFTooLarge = errors.New("gelf: message too large")
+
+// chunks returns the number of datagrams of the message in w.buf.
+func (w *GELFWriter) chunks() int {
+	if w.buf.Len() <= w.opts.ChunkSize {
+		return 1
+	}
+	size := w.opts.ChunkSize - gelfChunkHeader
+	return (w.buf.Len() + size - 1) / size
+}
+
+// sendStream sends the null delimited message in w.buf.
+func (w *GELFWriter) sendStream(conn net.Conn) error {
+	_, err := conn.Write(w.buf.Bytes())
+	return err
+}
+
+// sendChunks sends the compressed message in w.buf, chunked if needed.
+func (w *GELFWriter) sendChunks(conn net.Conn) error {
+	data := w.buf.Bytes()
+	n := w.chunks()
+	if n == 1 {
+		_, err := conn.Write(data)
+		return err
+	}
+
+	size := w.opts.ChunkSize - gelfChunkHeader
+	chunk := make([]byte, gelfChunkHeader, w.opts.ChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(n)
+	for i := 0; i < n; i++ {
+		chunk[10] = byte(i)
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := conn.Write(append(chunk[:gelfChunkHeader], data[i*size:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress writes msg to w.buf, compressed.
+func (w *GELFWriter) compress(msg []byte) error {
+	w.buf.Reset()
+	var zw io.WriteCloser
+	switch w.opts.Compression {
+	case GELFNone:
+		w.buf.Write(msg)
+		return nil
+	case GELFZlib:
+		zw = zlib.NewWriter(&w.buf)
+	default:
+		zw = gzip.NewWriter(&w.buf)
+	}
+	if _, err := zw.Write(msg); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Close closes the connection to the GELF input.
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.close()
+}
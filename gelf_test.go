@@ -0,0 +1,181 @@
+package lg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenGELF returns a GELF UDP input.
+func listenGELF(t *testing.T) net.PacketConn {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return pc
+}
+
+func newGELFTestLogger(t *testing.T, network, addr string, opts GELFWriterOptions) (*Logger, *GELFWriter) {
+	t.Helper()
+	w, err := NewGELFWriter(network, addr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	l := NewWithOptions(w, Options{Formatter: GELFFormatter, GELF: GELFOptions{Host: "host"}})
+	return l, w
+}
+
+func readDatagram(t *testing.T, pc net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 1<<16)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+// decodeGELF decodes a GELF message, checking its version and host.
+func decodeGELF(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("bad message %q: %v", b, err)
+	}
+	if m["version"] != "1.1" || m["host"] != "host" {
+		t.Fatalf("bad version or host: %v", m)
+	}
+	return m
+}
+
+func TestGELFWriterCompression(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		compression GELFCompression
+		magic       []byte
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", GELFGzip, []byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"zlib", GELFZlib, []byte{0x78}, func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"none", GELFNone, []byte("{"), func(r io.Reader) (io.Reader, error) { return r, nil }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := listenGELF(t)
+			l, _ := newGELFTestLogger(t, "udp", pc.LocalAddr().String(), GELFWriterOptions{Compression: tt.compression})
+			l.Warn("hello", "user", "bob")
+
+			data := readDatagram(t, pc)
+			if !bytes.HasPrefix(data, tt.magic) {
+				t.Fatalf("datagram starts with % x, want % x", data[:2], tt.magic)
+			}
+			r, err := tt.decompress(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := decodeGELF(t, b)
+			if m["short_message"] != "hello" || m["_user"] != "bob" {
+				t.Errorf("got %v", m)
+			}
+		})
+	}
+}
+
+func TestGELFWriterChunks(t *testing.T) {
+	pc := listenGELF(t)
+	const chunkSize = 200
+	l, _ := newGELFTestLogger(t, "udp", pc.LocalAddr().String(), GELFWriterOptions{
+		Compression: GELFNone,
+		ChunkSize:   chunkSize,
+	})
+	msg := strings.Repeat("0123456789", 100)
+	l.Info(msg)
+
+	var id []byte
+	var data []byte
+	for i, n := 0, 1; i < n; i++ {
+		chunk := readDatagram(t, pc)
+		if len(chunk) > chunkSize || len(chunk) <= gelfChunkHeader {
+			t.Fatalf("chunk %d has %d bytes", i, len(chunk))
+		}
+		if chunk[0] != 0x1e || chunk[1] != 0x0f {
+			t.Fatalf("chunk %d has magic % x", i, chunk[:2])
+		}
+		if i == 0 {
+			id, n = chunk[2:10], int(chunk[11])
+			if n < 2 {
+				t.Fatalf("message sent in %d chunks", n)
+			}
+		}
+		if !bytes.Equal(chunk[2:10], id) {
+			t.Errorf("chunk %d has message ID % x, want % x", i, chunk[2:10], id)
+		}
+		if int(chunk[10]) != i || int(chunk[11]) != n {
+			t.Errorf("chunk %d is numbered %d of %d, want %d of %d", i, chunk[10], chunk[11], i, n)
+		}
+		data = append(data, chunk[gelfChunkHeader:]...)
+	}
+	if m := decodeGELF(t, data); m["short_message"] != msg {
+		t.Errorf("reassembled message is %v", m["short_message"])
+	}
+}
+
+func TestGELFWriterTooLarge(t *testing.T) {
+	pc := listenGELF(t)
+	_, w := newGELFTestLogger(t, "udp", pc.LocalAddr().String(), GELFWriterOptions{
+		Compression: GELFNone,
+		ChunkSize:   gelfChunkHeader + 1,
+	})
+	if _, err := w.Write(make([]byte, gelfMaxChunks+1)); !errors.Is(err, errGELFTooLarge) {
+		t.Fatalf("got %v, want %v", err, errGELFTooLarge)
+	}
+	if w.conn.conn == nil {
+		t.Error("the connection was dropped for a message too large")
+	}
+}
+
+func TestGELFWriterTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	l, _ := newGELFTestLogger(t, "tcp", ln.Addr().String(), GELFWriterOptions{})
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	l.Info("one")
+	l.Info("two")
+
+	// the messages are uncompressed and delimited by a null byte
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"one", "two"} {
+		b, err := r.ReadBytes(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := decodeGELF(t, b[:len(b)-1]); m["short_message"] != want {
+			t.Errorf("got %v, want %q", m["short_message"], want)
+		}
+	}
+}
@@ -2,117 +2,169 @@ package lg
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// enabled reports whether a record of level would be written or published.
+func (l *Logger) enabled(level Level) bool {
+	return atomic.LoadUint32(&l.isDiscard) == 0 &&
+		(atomic.LoadInt32(&l.level) <= int32(level) || l.publishes(level))
+}
+
+// logFrame logs a record whose caller is frame, for the records not logged
+// from their caller.
+func (l *Logger) logFrame(level Level, frame runtime.Frame, withCaller bool, msg any, keyvals ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	l.output(l.newRecord(level, l.timeFunc(time.Now()), withCaller, []runtime.Frame{frame}, msg, keyvals))
+}
+
 func (l *Logger) handle(level Level, ts time.Time, frames []runtime.Frame, msg any, keyvals ...any) {
-	var kvs []any
+	l.output(l.newRecord(level, ts, l.reportCaller, frames, msg, keyvals))
+}
 
-	if level != NoLevel {
-		kvs = append(kvs, LevelKey, level)
+func (l *Logger) handleC(level Level, ts time.Time, frames []runtime.Frame, msg any, keyvals ...any) {
+	if len(frames) == 0 || frames[0].PC == 0 {
+		l.ErrorC("no frames")
+	}
+	l.output(l.newRecord(level, ts, true, frames, msg, keyvals))
+}
+
+// newRecord builds the record for a log call.
+func (l *Logger) newRecord(level Level, ts time.Time, withCaller bool, frames []runtime.Frame, msg any, keyvals []any) *Record {
+	r := &Record{
+		Time:   ts,
+		Level:  level,
+		Prefix: l.prefix,
 	}
 
-	if l.reportCaller && len(frames) > 0 && frames[0].PC != 0 {
+	if withCaller && len(frames) > 0 && frames[0].PC != 0 {
 		file, line, fn := l.location(frames)
 		if file != "" {
-			caller := l.callerFormatter(file, line, fn)
-			kvs = append(kvs, CallerKey, caller)
+			r.Caller = l.callerFormatter(file, line, fn)
+			r.file, r.line, r.function = file, line, fn
 		}
 	}
 
-	if l.prefix != "" {
-		kvs = append(kvs, PrefixKey, l.prefix)
-	}
-
 	if msg != nil {
-		if m := fmt.Sprint(msg); m != "" {
-			kvs = append(kvs, MessageKey, m)
-		}
+		r.Message = fmt.Sprint(msg)
 	}
 
+	l.mu.RLock()
+	transform, errorStack := l.keyTransform, l.errorStack
+	l.mu.RUnlock()
+	r.Fields = make([]Field, 0, (len(l.fields)+len(keyvals)+1)/2)
 	// append logger fields
-	kvs = append(kvs, l.fields...)
-	if len(l.fields)%2 != 0 {
-		kvs = append(kvs, ErrMissingValue)
+	r.Fields = l.appendFields(r.Fields, l.fields, transform)
+	// append the rest
+	r.Fields = l.appendFields(r.Fields, keyvals, transform)
+	// the records of panics have the stack of the panic
+	if errorStack && level >= ErrorLevel && level != NoLevel && !hasField(r.Fields, "stack") {
+		r.Fields = append(r.Fields, Field{"stack", callerStack()})
 	}
+	return r
+}
 
-	// append the rest
-	kvs = append(kvs, keyvals...)
-	if len(keyvals)%2 != 0 {
-		kvs = append(kvs, ErrMissingValue)
+func hasField(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
 	}
+	return false
+}
 
-	if l.reportTimestamp && !ts.IsZero() {
-		kvs = append(kvs, TimestampKey, ts)
+// output formats r and writes it to the logger output, then publishes it.
+func (l *Logger) output(r *Record) {
+	// errors are reported once the lock is released, so the error handler
+	// can log through this logger.
+	written, err := l.write(r)
+	if err != nil {
+		l.reportError(err)
 	}
+	l.publish(r, written)
+}
 
+// write reports whether the level of r is enabled, in which case it
+// tried to write it.
+func (l *Logger) write(r *Record) (bool, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	defer l.b.Reset()
+	for i, f := range r.Fields {
+		r.Fields[i].Value = l.encodeValue(f.Value)
+	}
+	// the record may only be logged to be published
+	if atomic.LoadInt32(&l.level) > int32(r.Level) {
+		return false, nil
+	}
+	l.sinks.mem.Add(*r)
 	switch l.formatter {
 	case JSONFormatter:
-		l.jsonFormatter(kvs...)
-		// WriteTo will reset the buffer
-		l.b.WriteTo(l.w) //nolint: errcheck
+		l.jsonFormatter(r)
+	case SyslogFormatter:
+		l.syslogFormatter(r)
+	case JournalFormatter:
+		l.journalFormatter(r)
+	case GELFFormatter:
+		l.gelfFormatter(r)
 	default:
-		l.textFormatter(kvs...)
-	}
-}
-
-func (l *Logger) handleC(level Level, ts time.Time, frames []runtime.Frame, msg any, keyvals ...any) {
-	var kvs []any
-
-	if level != NoLevel {
-		kvs = append(kvs, LevelKey, level)
+		l.textFormatter(r)
 	}
 
-	if len(frames) > 0 && frames[0].PC != 0 {
-		file, line, fn := l.location(frames)
-		if file != "" {
-			caller := l.callerFormatter(file, line, fn)
-			kvs = append(kvs, CallerKey, caller)
-		}
-	} else {
-		l.ErrorC("no frames")
-	}
-
-	if l.prefix != "" {
-		kvs = append(kvs, PrefixKey, l.prefix)
-	}
+	return true, l.writeOutput(l.b.Bytes())
+}
 
-	if msg != nil {
-		if m := fmt.Sprint(msg); m != "" {
-			kvs = append(kvs, MessageKey, m)
+// writeOutput writes p to the logger output, counting the records written
+// and the write errors, and falls back to stderr if enabled.
+// It must be called with the logger mutex held.
+func (l *Logger) writeOutput(p []byte) error {
+	_, err := l.w.Write(p)
+	if err == nil {
+		l.stats.records.Add(1)
+		return nil
+	}
+	l.stats.writeErrors.Add(1)
+	if l.fallbackToStderr && l.w != io.Writer(os.Stderr) {
+		if _, ferr := os.Stderr.Write(p); ferr == nil {
+			l.stats.fallbackWrites.Add(1)
 		}
 	}
+	return fmt.Errorf("write: %w", err)
+}
 
-	// append logger fields
-	kvs = append(kvs, l.fields...)
-	if len(l.fields)%2 != 0 {
-		kvs = append(kvs, ErrMissingValue)
-	}
-
-	// append the rest
-	kvs = append(kvs, keyvals...)
-	if len(keyvals)%2 != 0 {
-		kvs = append(kvs, ErrMissingValue)
-	}
-
-	if l.reportTimestamp && !ts.IsZero() {
-		kvs = append(kvs, TimestampKey, ts)
+// internalError reports a misuse of the logger to the error handler.
+// It panics in strict mode. It must not be called with the logger mutex held.
+func (l *Logger) internalError(err error) {
+	l.mu.RLock()
+	strict := l.strict
+	l.mu.RUnlock()
+	if strict {
+		panic("lg: " + err.Error())
 	}
+	l.reportError(err)
+}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	switch l.formatter {
-	case JSONFormatter:
-		l.jsonFormatter(kvs...)
-		// WriteTo will reset the buffer
-		l.b.WriteTo(l.w) //nolint: errcheck
-	default:
-		l.textFormatter(kvs...)
-	}
+// reportError passes err to the error handler, if any.
+// It must not be called with the logger mutex held.
+//
+// Errors raised while the handler runs are not reported, so a handler
+// logging through a failing logger does not recurse.
+func (l *Logger) reportError(err error) {
+	l.mu.RLock()
+	h := l.errorHandler
+	l.mu.RUnlock()
+	if h == nil || !atomic.CompareAndSwapUint32(l.inErrorHandler, 0, 1) {
+		return
+	}
+	defer atomic.StoreUint32(l.inErrorHandler, 0)
+	h(err)
 }
 
 func (l *Logger) helper(skip int) {
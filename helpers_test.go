@@ -0,0 +1,133 @@
+package lg
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errBrokenWriter = errors.New("broken")
+
+// brokenWriter fails every write.
+type brokenWriter struct{}
+
+func (brokenWriter) Write([]byte) (int, error) { return 0, errBrokenWriter }
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestWriteErrors(t *testing.T) {
+	var errs []error
+	l := NewWithOptions(brokenWriter{}, Options{
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	})
+	l.Info("one")
+	l.Info("two")
+	l.printfs("rdthree\n")
+
+	if len(errs) != 3 {
+		t.Fatalf("the error handler was called %d times, want 3", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, errBrokenWriter) {
+			t.Errorf("got %v, want the write error", err)
+		}
+	}
+	if s := l.Stats(); s.WriteErrors != 3 || s.Records != 0 || s.FallbackWrites != 0 {
+		t.Errorf("got %+v, want 3 write errors", s)
+	}
+}
+
+func TestWriteErrorsFallback(t *testing.T) {
+	l := NewWithOptions(brokenWriter{}, Options{FallbackToStderr: true})
+	out := captureStderr(t, func() {
+		l.Info("lost")
+		l.printfs("also lost\n")
+	})
+	if !strings.Contains(out, "lost") || !strings.Contains(out, "also lost") {
+		t.Errorf("stderr got %q, want both entries", out)
+	}
+	if s := l.Stats(); s.WriteErrors != 2 || s.FallbackWrites != 2 {
+		t.Errorf("got %+v, want 2 write errors written to stderr", s)
+	}
+}
+
+func TestErrorHandlerLoggingThroughFailingLogger(t *testing.T) {
+	old := Default()
+	defer SetDefault(old)
+
+	calls := 0
+	l := NewWithOptions(brokenWriter{}, Options{
+		ErrorHandler: func(err error) {
+			calls++
+			// fails again, which must not be reported
+			Error("logging failed", "err", err)
+		},
+	})
+	SetDefault(l)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Info("hello")
+		l.Info("hello")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the error handler deadlocked")
+	}
+	if calls != 2 {
+		t.Errorf("the error handler was called %d times, want 2", calls)
+	}
+	if s := l.Stats(); s.WriteErrors != 4 {
+		t.Errorf("got %d write errors, want 4", s.WriteErrors)
+	}
+}
+
+func TestWithWhileReportingErrors(t *testing.T) {
+	var calls int64
+	l := NewWithOptions(brokenWriter{}, Options{
+		ErrorHandler: func(error) { atomic.AddInt64(&calls, 1) },
+	})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Info("hello")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.With("i", i).Info("child")
+		}
+	}()
+	wg.Wait()
+	if atomic.LoadInt64(&calls) == 0 {
+		t.Error("the error handler was never called")
+	}
+}
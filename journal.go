@@ -0,0 +1,111 @@
+package lg
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// JournalOptions configures JournalFormatter.
+type JournalOptions struct {
+	// Identifier is the SYSLOG_IDENTIFIER of the entries. The default is the
+	// base name of the program.
+	Identifier string
+}
+
+// withDefaults returns opts with its unset fields set to their defaults.
+func (opts JournalOptions) withDefaults() JournalOptions {
+	if opts.Identifier == "" {
+		opts.Identifier = programName()
+	}
+	return opts
+}
+
+// journalFormatter writes r as a journal entry: PRIORITY, SYSLOG_IDENTIFIER,
+// MESSAGE, the CODE_ fields of the caller, then the prefix and the fields
+// under uppercased names, prefixed with FIELD_ when they are one of the
+// former.
+func (l *Logger) journalFormatter(r *Record) {
+	fields := make([]Field, 0, len(r.Fields)+7)
+	fields = append(fields,
+		Field{"PRIORITY", strconv.Itoa(syslogSeverity(r.Level))},
+		Field{"SYSLOG_IDENTIFIER", l.journal.Identifier},
+		Field{"MESSAGE", r.Message},
+	)
+	if r.file != "" {
+		fields = append(fields,
+			Field{"CODE_FILE", r.file},
+			Field{"CODE_LINE", strconv.Itoa(r.line)},
+			Field{"CODE_FUNC", r.function},
+		)
+	}
+	if r.Prefix != "" {
+		fields = append(fields, Field{journalFieldName(l.keys.Prefix), r.Prefix})
+	}
+	for _, f := range r.Fields {
+		if name := journalFieldName(f.Key); name != "" {
+			fields = append(fields, Field{name, textValue(f.Value)})
+		}
+	}
+
+	for _, f := range dedupFields(fields, l.duplicateKeys) {
+		l.writeJournalField(f.Key, f.Value.(string))
+	}
+}
+
+// writeJournalField writes a field of a journal entry, in its binary form
+// when the value holds a newline.
+func (l *Logger) writeJournalField(name, value string) {
+	l.b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		l.b.WriteByte('=')
+		l.b.WriteString(value)
+		l.b.WriteByte('\n')
+		return
+	}
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	l.b.WriteByte('\n')
+	l.b.Write(size[:])
+	l.b.WriteString(value)
+	l.b.WriteByte('\n')
+}
+
+// journalReserved are the fields of the entries set by the formatter.
+var journalReserved = map[string]bool{
+	"PRIORITY": true, "SYSLOG_IDENTIFIER": true, "MESSAGE": true,
+	"CODE_FILE": true, "CODE_LINE": true, "CODE_FUNC": true,
+}
+
+// journalFieldName returns key as the name of a field of the logger or the
+// record, which must not replace the fields set by the formatter.
+func journalFieldName(key string) string {
+	name := journalName(key)
+	if journalReserved[name] {
+		return "FIELD_" + name
+	}
+	return name
+}
+
+// journalName returns key as a journal field name: at most 64 uppercase
+// letters, digits and underscores, starting with a letter. It is empty
+// when key has no letter.
+func journalName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			b = append(b, c-'a'+'A')
+		case c >= 'A' && c <= 'Z':
+			b = append(b, c)
+		case len(b) == 0:
+			// names start with a letter
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,103 @@
+package lg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// defaultJournalSocket is the socket of systemd-journald for the native protocol.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalWriter writes the entries of JournalFormatter to systemd-journald,
+// one entry per Write. Like SyslogWriter, it reconnects when a write fails.
+type JournalWriter struct {
+	path string
+
+	mu   sync.Mutex
+	conn redialer
+}
+
+// NewJournalWriter returns a writer to the journal socket at path, the
+// socket of systemd-journald when path is empty.
+//
+// The entries too large for a datagram are written to an unlinked temporary
+// file in /dev/shm, whose descriptor is passed to journald instead.
+func NewJournalWriter(path string) (*JournalWriter, error) {
+	if path == "" {
+		path = defaultJournalSocket
+	}
+	w := &JournalWriter{path: path}
+	w.conn.dial = w.dial
+	if err := w.conn.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *JournalWriter) dial(d *net.Dialer) (net.Conn, error) {
+	conn, err := d.Dial("unixgram", w.path)
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+	return conn, nil
+}
+
+// Write sends p as a journal entry.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.conn.write(func(conn net.Conn) error { return journalSend(conn, p) }); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// journalSend writes the entry p to conn, or passes it in a file when it
+// is too large for a datagram.
+func journalSend(conn net.Conn, p []byte) error {
+	_, err := conn.Write(p)
+	if !isOversized(err) {
+		return err
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "lg-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+	// net refuses to send messages over connected datagram sockets
+	raw, err := conn.(syscall.Conn).SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(f.Fd()))
+	werr := raw.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+func isOversized(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// Close closes the connection to journald.
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.close()
+}
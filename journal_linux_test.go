@@ -0,0 +1,111 @@
+package lg
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenJournal returns a journal socket and its path.
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn, path
+}
+
+// readJournal reads an entry from the journal socket conn, from the file
+// whose descriptor is passed when the entry is not in the datagram.
+func readJournal(t *testing.T, conn *net.UnixConn) []byte {
+	t.Helper()
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oobn == 0 {
+		return buf[:n]
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("bad control message: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("bad rights: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+	b, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestJournalWriter(t *testing.T) {
+	conn, path := listenJournal(t)
+	w, err := NewJournalWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := NewWithOptions(w, Options{Formatter: JournalFormatter, Journal: JournalOptions{Identifier: "app"}})
+
+	l.Warn("two\nlines", "user", "bob")
+	want := [][2]string{
+		{"PRIORITY", "4"},
+		{"SYSLOG_IDENTIFIER", "app"},
+		{"MESSAGE", "two\nlines"},
+		{"USER", "bob"},
+	}
+	got := parseJournal(t, readJournal(t, conn))
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJournalWriterOversized(t *testing.T) {
+	conn, path := listenJournal(t)
+	w, err := NewJournalWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	l := NewWithOptions(w, Options{Formatter: JournalFormatter, Journal: JournalOptions{Identifier: "app"}})
+
+	// larger than the socket buffers, so too large for a datagram
+	big := strings.Repeat("x", 8<<20)
+	l.Info("big", "data", big)
+
+	entry := readJournal(t, conn)
+	if !bytes.HasSuffix(entry, []byte("DATA="+big+"\n")) {
+		t.Fatalf("the entry passed by descriptor lacks its data (%d bytes)", len(entry))
+	}
+	fields := parseJournal(t, entry)
+	if fields[2] != [2]string{"MESSAGE", "big"} {
+		t.Errorf("got %q, want the message", fields[2])
+	}
+
+	// the temporary file is unlinked once passed
+	if matches, _ := filepath.Glob("/dev/shm/lg-journal-*"); len(matches) > 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
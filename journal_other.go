@@ -0,0 +1,26 @@
+//go:build !linux
+
+package lg
+
+import "errors"
+
+var errNoJournal = errors.New("journal: only available on linux")
+
+// JournalWriter writes the entries of JournalFormatter to systemd-journald.
+// It is only available on linux.
+type JournalWriter struct{}
+
+// NewJournalWriter returns an error, journald is only available on linux.
+func NewJournalWriter(path string) (*JournalWriter, error) {
+	return nil, errNoJournal
+}
+
+// Write returns an error, journald is only available on linux.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	return 0, errNoJournal
+}
+
+// Close does nothing.
+func (w *JournalWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package lg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// parseJournal parses an entry of the journal native protocol.
+func parseJournal(t *testing.T, b []byte) [][2]string {
+	t.Helper()
+	var fields [][2]string
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			t.Fatalf("unterminated field %q", b)
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			b = b[i+1:]
+			j := bytes.IndexByte(b, '\n')
+			if j < 0 {
+				t.Fatalf("unterminated value of %s", name)
+			}
+			fields = append(fields, [2]string{name, string(b[:j])})
+			b = b[j+1:]
+			continue
+		}
+		b = b[i+1:]
+		if len(b) < 8 {
+			t.Fatalf("truncated size of %s", name)
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		if uint64(len(b)) < n+1 || b[n] != '\n' {
+			t.Fatalf("bad binary value of %s", name)
+		}
+		fields = append(fields, [2]string{name, string(b[:n])})
+		b = b[n+1:]
+	}
+	return fields
+}
+
+func TestJournalFormatter(t *testing.T) {
+	var b bytes.Buffer
+	l := NewWithOptions(&b, Options{
+		Formatter: JournalFormatter,
+		Journal:   JournalOptions{Identifier: "app"},
+		Prefix:    "db",
+	})
+	l.Error("query failed\nretrying", "rows", 3, "sql", "SELECT 1\nFROM t", "9lives", true)
+
+	want := [][2]string{
+		{"PRIORITY", "3"},
+		{"SYSLOG_IDENTIFIER", "app"},
+		{"MESSAGE", "query failed\nretrying"},
+		{"PREFIX", "db"},
+		{"ROWS", "3"},
+		{"SQL", "SELECT 1\nFROM t"},
+		{"LIVES", "true"},
+	}
+	if got := parseJournal(t, b.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestJournalFormatterReservedFields(t *testing.T) {
+	var b bytes.Buffer
+	l := NewWithOptions(&b, Options{
+		Formatter:     JournalFormatter,
+		Journal:       JournalOptions{Identifier: "app"},
+		ReportCaller:  true,
+		DuplicateKeys: DuplicateKeysLastWins,
+	})
+	l.Info("hello", "message", "spoofed", "priority", 0, "code_line", 1, "Syslog-Identifier", "other")
+
+	got := map[string]string{}
+	for _, f := range parseJournal(t, b.Bytes()) {
+		if _, dup := got[f[0]]; dup {
+			t.Fatalf("field %s written twice", f[0])
+		}
+		got[f[0]] = f[1]
+	}
+	for name, want := range map[string]string{
+		"MESSAGE":                 "hello",
+		"PRIORITY":                "6",
+		"SYSLOG_IDENTIFIER":       "app",
+		"FIELD_MESSAGE":           "spoofed",
+		"FIELD_PRIORITY":          "0",
+		"FIELD_CODE_LINE":         "1",
+		"FIELD_SYSLOG_IDENTIFIER": "other",
+	} {
+		if got[name] != want {
+			t.Errorf("%s = %q, want %q", name, got[name], want)
+		}
+	}
+	if got["CODE_LINE"] == "1" || got["CODE_FILE"] == "" {
+		t.Errorf("the caller fields were replaced: %q", got)
+	}
+}
@@ -1,70 +1,276 @@
 package lg
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
+	"unicode/utf8"
 )
 
-func (l *Logger) jsonFormatter(keyvals ...any) {
-	m := make(map[string]any, len(keyvals)/2)
-	for i := 0; i < len(keyvals); i += 2 {
-		switch keyvals[i] {
-		case TimestampKey:
-			if t, ok := keyvals[i+1].(time.Time); ok {
-				m[TimestampKey] = t.Format(l.timeFormat)
-			}
-		case LevelKey:
-			if level, ok := keyvals[i+1].(Level); ok {
-				m[LevelKey] = level.String()
-			}
-		case CallerKey:
-			if caller, ok := keyvals[i+1].(string); ok {
-				m[CallerKey] = caller
-			}
-		case PrefixKey:
-			if prefix, ok := keyvals[i+1].(string); ok {
-				m[PrefixKey] = prefix
-			}
-		case MessageKey:
-			if msg := keyvals[i+1]; msg != nil {
-				m[MessageKey] = fmt.Sprint(msg)
-			}
-		default:
-			var (
-				key string
-				val any
-			)
-			switch k := keyvals[i].(type) {
-			case fmt.Stringer:
-				key = k.String()
-			case error:
-				key = k.Error()
-			default:
-				key = fmt.Sprint(k)
-			}
-			switch v := keyvals[i+1].(type) {
-			case error:
-				val = v.Error()
-			case fmt.Stringer:
-				val = v.String()
-			default:
-				val = v
+func (l *Logger) jsonFormatter(r *Record) {
+	var fields []Field
+	switch l.jsonProfile {
+	case JSONProfileECS:
+		fields = l.ecsFields(r)
+	case JSONProfileGCP:
+		fields = l.gcpFields(r)
+	default:
+		fields = l.jsonFields(r)
+	}
+	fields = dedupFields(fields, l.duplicateKeys)
+
+	e := jsonEncoder{b: &l.b}
+	e.writeObject(fields)
+	l.b.WriteByte('\n')
+}
+
+func (l *Logger) jsonFields(r *Record) []Field {
+	keys := l.keys
+	fields := make([]Field, 0, len(r.Fields)+5)
+	if l.reportTimestamp && !r.Time.IsZero() {
+		fields = append(fields, Field{keys.Timestamp, r.Time.Format(l.timeFormat)})
+	}
+	if lvl := r.Level.String(); lvl != "" {
+		fields = append(fields, Field{keys.Level, lvl})
+	}
+	if r.Caller != "" {
+		fields = append(fields, Field{keys.Caller, r.Caller})
+	}
+	if r.Prefix != "" {
+		fields = append(fields, Field{keys.Prefix, r.Prefix})
+	}
+	if r.Message != "" {
+		fields = append(fields, Field{keys.Message, r.Message})
+	}
+	return append(fields, r.Fields...)
+}
+
+// ecsVersion is the version of the Elastic Common Schema of JSONProfileECS.
+const ecsVersion = "1.6.0"
+
+func (l *Logger) ecsFields(r *Record) []Field {
+	fields := make([]Field, 0, len(r.Fields)+8)
+	if l.reportTimestamp && !r.Time.IsZero() {
+		fields = append(fields, Field{"@timestamp", r.Time.Format(time.RFC3339Nano)})
+	}
+	if lvl := r.Level.String(); lvl != "" {
+		fields = append(fields, Field{"log.level", lvl})
+	}
+	if r.Message != "" {
+		fields = append(fields, Field{"message", r.Message})
+	}
+	if r.Prefix != "" {
+		fields = append(fields, Field{"log.logger", r.Prefix})
+	}
+	if r.file != "" {
+		fields = append(fields,
+			Field{"log.origin.file.name", r.file},
+			Field{"log.origin.file.line", int64(r.line)},
+			Field{"log.origin.function", r.function},
+		)
+	}
+	fields = append(fields, Field{"ecs.version", ecsVersion})
+	return append(fields, r.Fields...)
+}
+
+// gcpTraceKey is the field moved to the trace of the entry by JSONProfileGCP.
+const gcpTraceKey = "trace"
+
+func (l *Logger) gcpFields(r *Record) []Field {
+	keys := l.keys
+	fields := make([]Field, 0, len(r.Fields)+5)
+	if l.reportTimestamp && !r.Time.IsZero() {
+		fields = append(fields, Field{"time", r.Time.Format(time.RFC3339Nano)})
+	}
+	fields = append(fields, Field{"severity", gcpSeverity(r.Level)})
+	if r.Message != "" {
+		fields = append(fields, Field{"message", r.Message})
+	}
+	if r.file != "" {
+		fields = append(fields, Field{"logging.googleapis.com/sourceLocation", []Field{
+			{"file", r.file},
+			{"line", strconv.Itoa(r.line)},
+			{"function", r.function},
+		}})
+	}
+	if r.Prefix != "" {
+		fields = append(fields, Field{keys.Prefix, r.Prefix})
+	}
+	for _, f := range r.Fields {
+		if f.Key == gcpTraceKey {
+			f.Key = "logging.googleapis.com/trace"
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// gcpSeverity returns the Cloud Logging severity of level.
+func gcpSeverity(level Level) string {
+	switch {
+	case level == NoLevel:
+		return "DEFAULT"
+	case level >= FatalLevel:
+		return "CRITICAL"
+	case level >= ErrorLevel:
+		return "ERROR"
+	case level >= WarnLevel:
+		return "WARNING"
+	case level >= InfoLevel:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// jsonEncoder streams JSON values to a buffer, keeping object keys in
+// the order they are given.
+type jsonEncoder struct {
+	b *bytes.Buffer
+}
+
+func (e *jsonEncoder) writeObject(fields []Field) {
+	e.b.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			e.b.WriteByte(',')
+		}
+		e.writeString(f.Key)
+		e.b.WriteByte(':')
+		e.writeValue(f.Value)
+	}
+	e.b.WriteByte('}')
+}
+
+func (e *jsonEncoder) writeValue(v any) {
+	switch v := v.(type) {
+	case nil:
+		e.b.WriteString("null")
+	case string:
+		e.writeString(v)
+	case bool:
+		e.b.WriteString(strconv.FormatBool(v))
+	case int:
+		e.b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int8:
+		e.b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int16:
+		e.b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int32:
+		e.b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		e.b.WriteString(strconv.FormatInt(v, 10))
+	case uint:
+		e.b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		e.b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		e.b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		e.b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		e.b.WriteString(strconv.FormatUint(v, 10))
+	case float32:
+		e.writeFloat(float64(v), 32)
+	case float64:
+		e.writeFloat(v, 64)
+	case []Field:
+		e.writeObject(v)
+	case []any:
+		e.b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				e.b.WriteByte(',')
 			}
-			m[key] = val
+			e.writeValue(item)
 		}
+		e.b.WriteByte(']')
+	case json.Marshaler:
+		e.writeMarshal(v)
+	case error:
+		e.writeString(v.Error())
+	case fmt.Stringer:
+		e.writeString(v.String())
+	default:
+		e.writeMarshal(v)
 	}
-	msg := fmt.Sprint(m)
-	if saveMem {
-		ss.Add(msg)
+}
+
+func (e *jsonEncoder) writeFloat(f float64, bits int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		// not representable in JSON
+		e.writeString(strconv.FormatFloat(f, 'g', -1, bits))
+		return
 	}
+	e.b.WriteString(strconv.FormatFloat(f, 'g', -1, bits))
+}
+
+func (e *jsonEncoder) writeMarshal(v any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		e.writeString(fmt.Sprintf("!ERROR: %v", err))
+		return
+	}
+	e.b.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
 
-	if usePub && pub != nil {
-		pub.Publish(topicPub, map[string]any{
-			"log": msg,
-		})
+const hexDigits = "0123456789abcdef"
+
+// writeString writes s as a JSON string, without HTML escaping.
+func (e *jsonEncoder) writeString(s string) {
+	e.b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			e.b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				e.b.WriteByte('\\')
+				e.b.WriteByte(c)
+			case '\n':
+				e.b.WriteString(`\n`)
+			case '\r':
+				e.b.WriteString(`\r`)
+			case '\t':
+				e.b.WriteString(`\t`)
+			default:
+				e.b.WriteString(`\u00`)
+				e.b.WriteByte(hexDigits[c>>4])
+				e.b.WriteByte(hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			e.b.WriteString(s[start:i])
+			e.b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 break JavaScript parsers.
+		if r == '\u2028' || r == '\u2029' {
+			e.b.WriteString(s[start:i])
+			e.b.WriteString(`\u202`)
+			e.b.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
 	}
-	e := json.NewEncoder(&l.b)
-	e.SetEscapeHTML(false)
-	_ = e.Encode(m)
+	e.b.WriteString(s[start:])
+	e.b.WriteByte('"')
 }
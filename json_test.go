@@ -0,0 +1,146 @@
+package lg
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// newJSONTestLogger returns a logger writing JSON to b, at testTime, with
+// the "pre" prefix, the caller "file.go:1" and the fields app=x and z=1.
+func newJSONTestLogger(b *bytes.Buffer, o Options) *Logger {
+	o.Formatter = JSONFormatter
+	o.ReportTimestamp = true
+	o.ReportCaller = true
+	o.TimeFormat = time.RFC3339
+	o.TimeFunction = func(time.Time) time.Time { return testTime }
+	o.CallerFormatter = func(string, int, string) string { return "file.go:1" }
+	o.Prefix = "pre"
+	o.Fields = []any{"app", "x", "z", 1}
+	return NewWithOptions(b, o)
+}
+
+func TestJSONKeyOrder(t *testing.T) {
+	var b bytes.Buffer
+	l := newJSONTestLogger(&b, Options{})
+	l.Info("hi", "b", 2, "a", 1)
+
+	want := `{"time":"2024-01-02T03:04:05Z","level":"info","caller":"file.go:1","prefix":"pre","msg":"hi","app":"x","z":1,"b":2,"a":1}` + "\n"
+	if got := b.String(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONDuplicateKeys(t *testing.T) {
+	for _, tt := range []struct {
+		policy DuplicateKeyPolicy
+		want   string
+	}{
+		{DuplicateKeysLastWins, `"app":"y","z":1,"b":3`},
+		{DuplicateKeysFirstWins, `"app":"x","z":1,"b":2`},
+		{DuplicateKeysSuffix, `"app":"x","z":1,"b":2,"app_1":"y","b_1":3`},
+	} {
+		var b bytes.Buffer
+		l := newJSONTestLogger(&b, Options{DuplicateKeys: tt.policy})
+		l.Info("hi", "b", 2, "app", "y", "b", 3)
+
+		want := `{"time":"2024-01-02T03:04:05Z","level":"info","caller":"file.go:1","prefix":"pre","msg":"hi",` + tt.want + "}\n"
+		if got := b.String(); got != want {
+			t.Errorf("policy %d:\ngot  %s\nwant %s", tt.policy, got, want)
+		}
+	}
+}
+
+func TestDedupFields(t *testing.T) {
+	fields := []Field{{"x", 1}, {"x", 2}, {"x_1", 3}, {"y", 4}, {"x", 5}}
+	for _, tt := range []struct {
+		policy DuplicateKeyPolicy
+		want   []Field
+	}{
+		{DuplicateKeysLastWins, []Field{{"x", 5}, {"x_1", 3}, {"y", 4}}},
+		{DuplicateKeysFirstWins, []Field{{"x", 1}, {"x_1", 3}, {"y", 4}}},
+		// the user key x_1 collides with the renamed second x
+		{DuplicateKeysSuffix, []Field{{"x", 1}, {"x_1", 2}, {"x_1_1", 3}, {"y", 4}, {"x_2", 5}}},
+	} {
+		got := dedupFields(fields, tt.policy)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: got %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+	if fields[0].Value != 1 || fields[1].Key != "x" {
+		t.Errorf("the input was modified: %v", fields)
+	}
+}
+
+// warnHere logs hi at warn level with the trace field, and returns the
+// file, line and function of the call.
+func warnHere(l *Logger) (file string, line int, function string) {
+	pc, file, line, _ := runtime.Caller(0)
+	l.Warn("hi", "trace", "t")
+	return file, line + 1, runtime.FuncForPC(pc).Name()
+}
+
+func TestJSONProfileECS(t *testing.T) {
+	var b bytes.Buffer
+	l := newJSONTestLogger(&b, Options{JSONProfile: JSONProfileECS})
+	file, line, function := warnHere(l)
+
+	want := fmt.Sprintf(`{"@timestamp":"2024-01-02T03:04:05.000006Z","log.level":"warn","message":"hi","log.logger":"pre",`+
+		`"log.origin.file.name":%q,"log.origin.file.line":%d,"log.origin.function":%q,`+
+		`"ecs.version":"1.6.0","app":"x","z":1,"trace":"t"}`+"\n", file, line, function)
+	if got := b.String(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	b.Reset()
+	l.SetReportCaller(false)
+	l.Warn("hi")
+	want = `{"@timestamp":"2024-01-02T03:04:05.000006Z","log.level":"warn","message":"hi","log.logger":"pre","ecs.version":"1.6.0","app":"x","z":1}` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("without the caller:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONProfileGCP(t *testing.T) {
+	var b bytes.Buffer
+	l := newJSONTestLogger(&b, Options{JSONProfile: JSONProfileGCP})
+	file, line, function := warnHere(l)
+
+	want := fmt.Sprintf(`{"time":"2024-01-02T03:04:05.000006Z","severity":"WARNING","message":"hi",`+
+		`"logging.googleapis.com/sourceLocation":{"file":%q,"line":"%d","function":%q},`+
+		`"prefix":"pre","app":"x","z":1,"logging.googleapis.com/trace":"t"}`+"\n", file, line, function)
+	if got := b.String(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	b.Reset()
+	l.SetReportCaller(false)
+	l.Print("hi")
+	want = `{"time":"2024-01-02T03:04:05.000006Z","severity":"DEFAULT","message":"hi","prefix":"pre","app":"x","z":1}` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("without the caller:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestGCPSeverity(t *testing.T) {
+	for _, tt := range []struct {
+		level Level
+		want  string
+	}{
+		{DebugLevel - 4, "DEBUG"},
+		{DebugLevel, "DEBUG"},
+		{InfoLevel, "INFO"},
+		{WarnLevel, "WARNING"},
+		{ErrorLevel, "ERROR"},
+		{FatalLevel, "CRITICAL"},
+		{FatalLevel + 4, "CRITICAL"},
+		{NoLevel, "DEFAULT"},
+	} {
+		if got := gcpSeverity(tt.level); got != tt.want {
+			t.Errorf("gcpSeverity(%d) = %s, want %s", tt.level, got, tt.want)
+		}
+	}
+}
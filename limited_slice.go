@@ -5,34 +5,52 @@ import (
 	"sync"
 )
 
+// LimitedSlice is a fixed-capacity, concurrency-safe circular buffer.
+// Once full, adding an element drops the oldest one.
+// Indexes go from the oldest element, 0, to the newest one, Len()-1.
 type LimitedSlice[T any] struct {
-	Slice []T
-	max   int
-	mu    sync.RWMutex
+	buf  []T
+	head int // index in buf of the oldest element
+	n    int // number of elements
+	mu   sync.RWMutex
 }
 
 func NewLimitedSlice[T any](max int) *LimitedSlice[T] {
+	if max < 0 {
+		max = 0
+	}
 	return &LimitedSlice[T]{
-		max: max,
+		buf: make([]T, max),
 	}
 }
 
+// at returns the position in buf of the element at index.
+func (ls *LimitedSlice[T]) at(index int) int {
+	return (ls.head + index) % len(ls.buf)
+}
+
 func (ls *LimitedSlice[T]) Add(element T) {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
 
-	if len(ls.Slice) == ls.max {
-		ls.Slice = ls.Slice[1:]
+	if len(ls.buf) == 0 {
+		return
 	}
-	ls.Slice = append(ls.Slice, element)
+	if ls.n < len(ls.buf) {
+		ls.buf[ls.at(ls.n)] = element
+		ls.n++
+		return
+	}
+	ls.buf[ls.head] = element
+	ls.head = (ls.head + 1) % len(ls.buf)
 }
 
 func (ls *LimitedSlice[T]) Get(index int) (T, error) {
 	ls.mu.RLock()
 	defer ls.mu.RUnlock()
 
-	if index >= 0 && index < len(ls.Slice) {
-		return ls.Slice[index], nil
+	if index >= 0 && index < ls.n {
+		return ls.buf[ls.at(index)], nil
 	}
 	return *new(T), fmt.Errorf("index out of range")
 }
@@ -40,20 +58,103 @@ func (ls *LimitedSlice[T]) Get(index int) (T, error) {
 func (ls *LimitedSlice[T]) Delete(index int) error {
 	ls.mu.Lock()
 	defer ls.mu.Unlock()
-	if index >= 0 && index < len(ls.Slice) {
-		ls.Slice = append(ls.Slice[:index], ls.Slice[index+1:]...)
-		return nil
+	if index < 0 || index >= ls.n {
+		return fmt.Errorf("index out of range")
+	}
+	for i := index; i < ls.n-1; i++ {
+		ls.buf[ls.at(i)] = ls.buf[ls.at(i+1)]
 	}
-	return fmt.Errorf("index out of range")
+	ls.n--
+	// release the reference held by the freed slot
+	ls.buf[ls.at(ls.n)] = *new(T)
+	return nil
 }
 
+// Range calls fn for each element, oldest first, until fn returns false.
 func (ls *LimitedSlice[T]) Range(fn func(T, int) bool) {
 	ls.mu.RLock()
 	defer ls.mu.RUnlock()
 
-	for i, element := range ls.Slice {
-		if !fn(element, i) {
+	for i := 0; i < ls.n; i++ {
+		if !fn(ls.buf[ls.at(i)], i) {
+			break
+		}
+	}
+}
+
+// RangeReverse calls fn for each element, newest first, until fn returns false.
+func (ls *LimitedSlice[T]) RangeReverse(fn func(T, int) bool) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	for i := ls.n - 1; i >= 0; i-- {
+		if !fn(ls.buf[ls.at(i)], i) {
 			break
 		}
 	}
-}
\ No newline at end of file
+}
+
+// Len returns the number of elements.
+func (ls *LimitedSlice[T]) Len() int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.n
+}
+
+// Cap returns the maximum number of elements.
+func (ls *LimitedSlice[T]) Cap() int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return len(ls.buf)
+}
+
+// Clear removes every element.
+func (ls *LimitedSlice[T]) Clear() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	for i := range ls.buf {
+		ls.buf[i] = *new(T)
+	}
+	ls.head, ls.n = 0, 0
+}
+
+// Snapshot returns a copy of the elements, oldest first.
+func (ls *LimitedSlice[T]) Snapshot() []T {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.last(ls.n)
+}
+
+// Last returns a copy of the n newest elements, oldest first.
+func (ls *LimitedSlice[T]) Last(n int) []T {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.last(n)
+}
+
+func (ls *LimitedSlice[T]) last(n int) []T {
+	if n > ls.n {
+		n = ls.n
+	}
+	if n <= 0 {
+		return nil
+	}
+	out := make([]T, n)
+	for i := range out {
+		out[i] = ls.buf[ls.at(ls.n-n+i)]
+	}
+	return out
+}
+
+// Resize changes the maximum number of elements, keeping the newest ones.
+func (ls *LimitedSlice[T]) Resize(max int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if max < 0 {
+		max = 0
+	}
+	kept := ls.last(max)
+	ls.buf = make([]T, max)
+	copy(ls.buf, kept)
+	ls.head, ls.n = 0, len(kept)
+}
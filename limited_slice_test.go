@@ -0,0 +1,177 @@
+package lg
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLimitedSliceWraparound(t *testing.T) {
+	ls := NewLimitedSlice[int](3)
+	for i := 1; i <= 7; i++ {
+		ls.Add(i)
+	}
+	if got, want := ls.Snapshot(), []int{5, 6, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	if ls.Len() != 3 || ls.Cap() != 3 {
+		t.Fatalf("Len(), Cap() = %d, %d, want 3, 3", ls.Len(), ls.Cap())
+	}
+	for i, want := range []int{5, 6, 7} {
+		if got, err := ls.Get(i); err != nil || got != want {
+			t.Errorf("Get(%d) = %d, %v, want %d", i, got, err, want)
+		}
+	}
+	if _, err := ls.Get(3); err == nil {
+		t.Error("Get(3) succeeded past the last element")
+	}
+	if _, err := ls.Get(-1); err == nil {
+		t.Error("Get(-1) succeeded")
+	}
+
+	var reversed []int
+	ls.RangeReverse(func(v, i int) bool {
+		reversed = append(reversed, v)
+		return true
+	})
+	if want := []int{7, 6, 5}; !reflect.DeepEqual(reversed, want) {
+		t.Errorf("RangeReverse visited %v, want %v", reversed, want)
+	}
+	var first []int
+	ls.Range(func(v, i int) bool {
+		first = append(first, v)
+		return i < 1
+	})
+	if want := []int{5, 6}; !reflect.DeepEqual(first, want) {
+		t.Errorf("Range stopped after %v, want %v", first, want)
+	}
+}
+
+func TestLimitedSliceZeroCapacity(t *testing.T) {
+	ls := NewLimitedSlice[int](0)
+	ls.Add(1)
+	if ls.Len() != 0 || ls.Snapshot() != nil {
+		t.Fatalf("Len(), Snapshot() = %d, %v, want 0, nil", ls.Len(), ls.Snapshot())
+	}
+}
+
+func TestLimitedSliceDelete(t *testing.T) {
+	ls := NewLimitedSlice[int](4)
+	for i := 1; i <= 6; i++ {
+		ls.Add(i)
+	}
+	// 3 4 5 6, wrapped around the buffer
+	if err := ls.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ls.Snapshot(), []int{3, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Delete(1), Snapshot() = %v, want %v", got, want)
+	}
+	if err := ls.Delete(3); err == nil {
+		t.Error("Delete(3) succeeded past the last element")
+	}
+	ls.Add(7)
+	ls.Add(8)
+	if got, want := ls.Snapshot(), []int{5, 6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Add, Snapshot() = %v, want %v", got, want)
+	}
+	ls.Clear()
+	if ls.Len() != 0 {
+		t.Fatalf("after Clear, Len() = %d", ls.Len())
+	}
+}
+
+func TestLimitedSliceResize(t *testing.T) {
+	ls := NewLimitedSlice[int](4)
+	for i := 1; i <= 6; i++ {
+		ls.Add(i)
+	}
+	ls.Resize(2)
+	if got, want := ls.Snapshot(), []int{5, 6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Resize(2), Snapshot() = %v, want %v", got, want)
+	}
+	ls.Resize(3)
+	ls.Add(7)
+	ls.Add(8)
+	if got, want := ls.Snapshot(), []int{6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Resize(3), Snapshot() = %v, want %v", got, want)
+	}
+	ls.Resize(-1)
+	if ls.Len() != 0 || ls.Cap() != 0 {
+		t.Fatalf("after Resize(-1), Len(), Cap() = %d, %d", ls.Len(), ls.Cap())
+	}
+}
+
+func TestLimitedSliceLast(t *testing.T) {
+	ls := NewLimitedSlice[int](3)
+	for i := 1; i <= 5; i++ {
+		ls.Add(i)
+	}
+	for _, tt := range []struct {
+		n    int
+		want []int
+	}{
+		{-1, nil},
+		{0, nil},
+		{2, []int{4, 5}},
+		{3, []int{3, 4, 5}},
+		{10, []int{3, 4, 5}},
+	} {
+		if got := ls.Last(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Last(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLimitedSliceConcurrent(t *testing.T) {
+	ls := NewLimitedSlice[int](10)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				ls.Add(i)
+				ls.Last(3)
+			}
+		}()
+	}
+	wg.Wait()
+	if ls.Len() != 10 {
+		t.Fatalf("Len() = %d, want 10", ls.Len())
+	}
+}
+
+// shiftingSlice is the previous implementation of LimitedSlice, dropping the
+// oldest element by reslicing.
+type shiftingSlice[T any] struct {
+	slice []T
+	max   int
+	mu    sync.RWMutex
+}
+
+func (s *shiftingSlice[T]) Add(element T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.slice) == s.max {
+		s.slice = s.slice[1:]
+	}
+	s.slice = append(s.slice, element)
+}
+
+func BenchmarkLimitedSliceAdd(b *testing.B) {
+	b.Run("ring", func(b *testing.B) {
+		ls := NewLimitedSlice[int](1000)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ls.Add(i)
+		}
+	})
+	b.Run("shifting", func(b *testing.B) {
+		s := &shiftingSlice[int]{max: 1000}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.Add(i)
+		}
+	})
+}
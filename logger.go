@@ -14,6 +14,9 @@ import (
 // ErrMissingValue is returned when a key is missing a value.
 var ErrMissingValue = fmt.Errorf("missing value")
 
+// ErrBadKey is returned when a key is not a string.
+var ErrBadKey = fmt.Errorf("key is not a string")
+
 // LoggerOption is an option for a logger.
 type LoggerOption = func(*Logger)
 
@@ -24,14 +27,42 @@ type Logger struct {
 	mu *sync.RWMutex
 
 	isDiscard uint32
-
-	level           int32
-	prefix          string
-	timeFunc        TimeFunction
-	timeFormat      string
-	callerOffset    int
-	callerFormatter CallerFormatter
-	formatter       Formatter
+	// inErrorHandler is set while the error handler runs. It is behind a
+	// pointer so that With copies the logger without racing with
+	// reportError, each child getting its own.
+	inErrorHandler *uint32
+	stats          *stats
+	sinks          *sinks
+
+	level            int32
+	prefix           string
+	timeFunc         TimeFunction
+	timeFormat       string
+	callerOffset     int
+	callerFormatter  CallerFormatter
+	formatter        Formatter
+	jsonProfile      JSONProfile
+	keys             Keys
+	keyTransform     KeyTransform
+	duplicateKeys    DuplicateKeyPolicy
+	durationFormat   DurationFormat
+	bytesFormat      BytesFormat
+	errorFormat      ErrorFormat
+	errorStack       bool
+	panicPolicy      PanicPolicy
+	ansiPolicy       ANSIPolicy
+	multilineBlock   bool
+	layout           TextLayout
+	pretty           PrettyOptions
+	isTerminal       bool
+	template         []templatePart
+	tree             TreeOptions
+	syslog           SyslogOptions
+	journal          JournalOptions
+	gelf             GELFOptions
+	errorHandler     func(error)
+	strict           bool
+	fallbackToStderr bool
 
 	reportCaller    bool
 	reportTimestamp bool
@@ -48,7 +79,7 @@ func (l *Logger) log(pkgCall bool, level Level, msg any, keyvals ...any) {
 	}
 
 	// check if the level is allowed
-	if atomic.LoadInt32(&l.level) > int32(level) {
+	if atomic.LoadInt32(&l.level) > int32(level) && !l.publishes(level) {
 		return
 	}
 
@@ -80,7 +111,7 @@ func (l *Logger) logC(pkgCall bool, level Level, msg any, keyvals ...any) {
 	}
 
 	// check if the level is allowed
-	if atomic.LoadInt32(&l.level) > int32(level) {
+	if atomic.LoadInt32(&l.level) > int32(level) && !l.publishes(level) {
 		return
 	}
 
@@ -112,7 +143,7 @@ func (l *Logger) Helper() {
 
 func (l *Logger) CheckError(err error) bool {
 	if err != nil {
-		ErrorC("", "err", err)
+		l.logC(false, ErrorLevel, "", "err", err)
 		return true
 	}
 	return false
@@ -182,6 +213,7 @@ func (l *Logger) SetOutput(w io.Writer) {
 		w = os.Stderr
 	}
 	l.w = w
+	l.isTerminal = isTerminal(w)
 	var isDiscard uint32
 	if w == io.Discard {
 		isDiscard = 1
@@ -196,6 +228,175 @@ func (l *Logger) SetFormatter(f Formatter) {
 	l.formatter = f
 }
 
+// SetDuplicateKeys sets the duplicate key policy.
+func (l *Logger) SetDuplicateKeys(policy DuplicateKeyPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.duplicateKeys = policy
+}
+
+// SetDurationFormat sets how time.Duration values are encoded.
+func (l *Logger) SetDurationFormat(format DurationFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.durationFormat = format
+}
+
+// SetBytesFormat sets how byte slices are encoded.
+func (l *Logger) SetBytesFormat(format BytesFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bytesFormat = format
+}
+
+// SetJSONProfile sets the schema of the JSON formatter.
+func (l *Logger) SetJSONProfile(profile JSONProfile) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.jsonProfile = profile
+}
+
+// SetKeys sets the keys of the built-in fields.
+func (l *Logger) SetKeys(keys Keys) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keys = keys.withDefaults()
+}
+
+// SetKeyTransform sets how the keys of the fields are rewritten, nil for none.
+func (l *Logger) SetKeyTransform(f KeyTransform) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keyTransform = f
+}
+
+// SetErrorFormat sets how error values are encoded.
+func (l *Logger) SetErrorFormat(format ErrorFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorFormat = format
+}
+
+// SetErrorStack sets whether the records at ErrorLevel and above get the
+// stack of the logging goroutine.
+func (l *Logger) SetErrorStack(stack bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorStack = stack
+}
+
+// SetPanicPolicy sets what happens once a recovered panic is logged.
+func (l *Logger) SetPanicPolicy(policy PanicPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.panicPolicy = policy
+}
+
+// SetANSIPolicy sets how escape sequences in user-supplied text are handled.
+func (l *Logger) SetANSIPolicy(policy ANSIPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ansiPolicy = policy
+}
+
+// SetMultilineBlock sets whether multi-line values are rendered as a block.
+func (l *Logger) SetMultilineBlock(block bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.multilineBlock = block
+}
+
+// SetLayout sets the line layout of the text formatter.
+func (l *Logger) SetLayout(layout TextLayout) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.layout = layout
+}
+
+// SetPrettyOptions sets the options of LayoutPretty.
+func (l *Logger) SetPrettyOptions(opts PrettyOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pretty = opts
+}
+
+// SetTemplate sets the line layout template of the text formatter.
+// An empty template restores the layout set with SetLayout.
+//
+// Parts are written {name}, {name:width}, {name|style} or {name:width|style}.
+// name is one of time, level, caller, prefix, msg, fields, or the key of a
+// field to render its value alone, in which case the field is left out of
+// {fields}. A positive width pads the part on the right, a negative one on
+// the left. For level, a width shorter than the level name truncates it.
+// style is a color accepted by Color, "level" for the level color, or "none".
+// Use {{ and }} for literal braces.
+//
+// The literal text next to a part, up to the first or from the last space
+// between two parts, is left out with the part when it renders empty, and
+// the spaces around empty parts collapse: "[{caller}] {prefix}: {msg}" is
+// written "hello" without caller nor prefix.
+func (l *Logger) SetTemplate(tmpl string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.template = nil
+	if tmpl != "" {
+		l.template = parseTemplate(tmpl)
+	}
+}
+
+// SetSyslogOptions sets the header and structured data of SyslogFormatter.
+func (l *Logger) SetSyslogOptions(opts SyslogOptions) {
+	opts = opts.withDefaults()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.syslog = opts
+}
+
+// SetJournalOptions sets the identifier of JournalFormatter.
+func (l *Logger) SetJournalOptions(opts JournalOptions) {
+	opts = opts.withDefaults()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.journal = opts
+}
+
+// SetGELFOptions sets the host of GELFFormatter.
+func (l *Logger) SetGELFOptions(opts GELFOptions) {
+	opts = opts.withDefaults()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gelf = opts
+}
+
+// SetTreeOptions sets how large nested values are rendered as a tree.
+func (l *Logger) SetTreeOptions(opts TreeOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tree = opts
+}
+
+// SetErrorHandler sets the function receiving the internal errors of the logger.
+func (l *Logger) SetErrorHandler(h func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorHandler = h
+}
+
+// SetStrict sets whether misuses of the logger panic.
+func (l *Logger) SetStrict(strict bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strict = strict
+}
+
+// SetFallbackToStderr sets whether entries the output fails to write are
+// written to stderr instead.
+func (l *Logger) SetFallbackToStderr(fallback bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fallbackToStderr = fallback
+}
+
 // SetCallerFormatter sets the caller formatter.
 func (l *Logger) SetCallerFormatter(f CallerFormatter) {
 	l.mu.Lock()
@@ -216,6 +417,19 @@ func (l *Logger) WithPrefix(prefix string) *Logger {
 	return l
 }
 
+// With returns a child logger adding keyvals to the fields of its records.
+// The child shares the output, the memory store, the publisher and the stats
+// of l, and starts with its settings.
+func (l *Logger) With(keyvals ...any) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c := *l
+	c.b = bytes.Buffer{}
+	c.inErrorHandler = new(uint32)
+	c.fields = append(l.fields[:len(l.fields):len(l.fields)], keyvals...)
+	return &c
+}
+
 // Debug prints a debug message.
 func (l *Logger) Debug(msg any, keyvals ...any) {
 	l.log(false, DebugLevel, msg, keyvals...)
@@ -250,14 +464,15 @@ func (l *Logger) ErrorC(msg any, keyvals ...any) {
 	l.logC(false, ErrorLevel, msg, keyvals...)
 }
 
-// Fatal prints a fatal message and exits.
+// Fatal prints a fatal message and exits, once the records waiting to be
+// published are published.
 func (l *Logger) Fatal(msg any, keyvals ...any) {
 	l.log(false, FatalLevel, msg, keyvals...)
-	os.Exit(1)
+	l.exit()
 }
 func (l *Logger) FatalC(msg any, keyvals ...any) {
 	l.logC(false, FatalLevel, msg, keyvals...)
-	os.Exit(1)
+	l.exit()
 }
 
 // Print prints a message with no level.
@@ -291,9 +506,9 @@ func (l *Logger) Errorf(format string, args ...any) {
 // Fatalf prints a fatal message with formatting and exits.
 func (l *Logger) Fatalf(format string, args ...any) {
 	l.log(false, FatalLevel, fmt.Sprintf(format, args...))
-	os.Exit(1)
+	l.exit()
 }
 
 func (l *Logger) Printfs(format string, args ...any) {
-	Printfs(format, args...)
+	l.printfs(format, args...)
 }
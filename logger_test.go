@@ -0,0 +1,68 @@
+package lg
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeysResolvedOnce(t *testing.T) {
+	var b bytes.Buffer
+	l := NewWithOptions(&b, Options{Formatter: JSONFormatter, Keys: Keys{Message: "message"}})
+
+	old := LevelKey
+	LevelKey = "severity"
+	defer func() { LevelKey = old }()
+	l.Info("hello")
+	if got, want := b.String(), `{"level":"info","message":"hello"}`+"\n"; got != want {
+		t.Fatalf("got %s want %s", got, want)
+	}
+
+	b.Reset()
+	l.SetKeys(Keys{})
+	l.Info("hello")
+	if got, want := b.String(), `{"severity":"info","msg":"hello"}`+"\n"; got != want {
+		t.Fatalf("after SetKeys, got %s want %s", got, want)
+	}
+}
+
+func TestSetKeyTransformConcurrent(t *testing.T) {
+	l := NewWithOptions(io.Discard, Options{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Info("hello", "userID", i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.SetKeyTransform(strings.ToUpper)
+			l.SetKeyTransform(nil)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSetErrorStackConcurrent(t *testing.T) {
+	l := NewWithOptions(io.Discard, Options{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Error("failed")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.SetErrorStack(i%2 == 0)
+		}
+	}()
+	wg.Wait()
+}
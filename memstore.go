@@ -0,0 +1,215 @@
+package lg
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSubscriberBuffer is the default number of records buffered per subscriber.
+const defaultSubscriberBuffer = 64
+
+// MemStore keeps the last records of a logger in memory, see SaveToMem,
+// and passes new records to its subscribers.
+type MemStore struct {
+	records *LimitedSlice[Record]
+
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// SlowSubscriberPolicy is what happens to the records sent to a subscriber
+// whose buffer is full.
+type SlowSubscriberPolicy uint8
+
+const (
+	// DropRecords drops the records the subscriber has no room for.
+	DropRecords SlowSubscriberPolicy = iota
+	// DisconnectSubscriber closes the subscriber channel.
+	DisconnectSubscriber
+)
+
+// SubscribeOptions configures a subscription.
+type SubscribeOptions struct {
+	// Buffer is the number of records buffered for the subscriber. The default is 64.
+	Buffer int
+	// Policy applies when the buffer is full. The default is DropRecords.
+	Policy SlowSubscriberPolicy
+}
+
+type subscriber struct {
+	ch     chan Record
+	filter Query
+	policy SlowSubscriberPolicy
+}
+
+// NewMemStore returns a store keeping the last max records.
+func NewMemStore(max int) *MemStore {
+	return &MemStore{
+		records: NewLimitedSlice[Record](max),
+		subs:    make(map[*subscriber]struct{}),
+	}
+}
+
+// Add adds a record, dropping the oldest one when the store is full,
+// and sends it to the matching subscribers.
+func (s *MemStore) Add(r Record) {
+	s.records.Add(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		if !sub.filter.Match(r) {
+			continue
+		}
+		select {
+		case sub.ch <- r:
+		default:
+			if sub.policy == DisconnectSubscriber {
+				delete(s.subs, sub)
+				close(sub.ch)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the records added from now on that
+// match filter, its pagination fields are ignored. cancel ends the
+// subscription and closes the channel, it is safe to call more than once.
+func (s *MemStore) Subscribe(filter Query, opts ...SubscribeOptions) (records <-chan Record, cancel func()) {
+	var o SubscribeOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = defaultSubscriberBuffer
+	}
+	sub := &subscriber{
+		ch:     make(chan Record, o.Buffer),
+		filter: filter,
+		policy: o.Policy,
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub.ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[sub]; ok {
+			delete(s.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Resize changes the number of records kept, keeping the newest ones.
+func (s *MemStore) Resize(max int) {
+	s.records.Resize(max)
+}
+
+// Len returns the number of records kept.
+func (s *MemStore) Len() int {
+	return s.records.Len()
+}
+
+// Clear removes the records kept.
+func (s *MemStore) Clear() {
+	s.records.Clear()
+}
+
+// Range calls fn for each record, oldest first, until fn returns false.
+func (s *MemStore) Range(fn func(Record, int) bool) {
+	s.records.Range(fn)
+}
+
+// Query is a filter over the records of a MemStore.
+// The zero Query matches every record.
+type Query struct {
+	// MinLevel and MaxLevel bound the levels matched, when set.
+	MinLevel, MaxLevel *Level
+	// Since and Until bound the times matched, when set.
+	Since, Until time.Time
+	// Prefix matches the records with this prefix, when set.
+	Prefix string
+	// Fields matches the records having each of these fields, with a value
+	// rendering as given in text output.
+	Fields map[string]string
+	// Message matches the records whose message contains it, when set.
+	Message string
+	// Text matches the records whose text, see Record.String, contains it, when set.
+	Text string
+
+	// NewestFirst returns the newest records first.
+	NewestFirst bool
+	// Offset skips the first matching records.
+	Offset int
+	// Limit is the maximum number of records returned, 0 means no limit.
+	Limit int
+}
+
+// Match reports whether r matches the filters of q.
+func (q Query) Match(r Record) bool {
+	if q.MinLevel != nil && r.Level < *q.MinLevel {
+		return false
+	}
+	if q.MaxLevel != nil && r.Level > *q.MaxLevel {
+		return false
+	}
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Time.After(q.Until) {
+		return false
+	}
+	if q.Prefix != "" && r.Prefix != q.Prefix {
+		return false
+	}
+	if q.Message != "" && !strings.Contains(r.Message, q.Message) {
+		return false
+	}
+	if q.Text != "" && !strings.Contains(r.String(), q.Text) {
+		return false
+	}
+	for key, want := range q.Fields {
+		found := false
+		for _, f := range r.Fields {
+			if f.Key == key && textValue(f.Value) == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns the records matching q.
+func (s *MemStore) Query(q Query) []Record {
+	all := s.records.Snapshot()
+	if q.NewestFirst {
+		for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+			all[i], all[j] = all[j], all[i]
+		}
+	}
+
+	var res []Record
+	skipped := 0
+	for _, r := range all {
+		if !q.Match(r) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		res = append(res, r)
+		if q.Limit > 0 && len(res) == q.Limit {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package lg
+
+import "testing"
+
+// received returns the records buffered in ch, and whether ch is closed.
+func received(ch <-chan Record) (msgs []string, closed bool) {
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				return msgs, true
+			}
+			msgs = append(msgs, r.Message)
+		default:
+			return msgs, false
+		}
+	}
+}
+
+func TestMemStoreSubscribeFilter(t *testing.T) {
+	s := NewMemStore(10)
+	warn := WarnLevel
+	ch, cancel := s.Subscribe(Query{
+		MinLevel: &warn,
+		Prefix:   "db",
+		Fields:   map[string]string{"table": "users"},
+		// pagination is ignored
+		Limit: 1,
+	})
+	defer cancel()
+
+	for _, r := range []Record{
+		{Level: ErrorLevel, Prefix: "db", Message: "match", Fields: []Field{{"table", "users"}}},
+		{Level: InfoLevel, Prefix: "db", Message: "low level", Fields: []Field{{"table", "users"}}},
+		{Level: ErrorLevel, Prefix: "http", Message: "other prefix", Fields: []Field{{"table", "users"}}},
+		{Level: ErrorLevel, Prefix: "db", Message: "other field", Fields: []Field{{"table", "orders"}}},
+		{Level: WarnLevel, Prefix: "db", Message: "match too", Fields: []Field{{"id", 1}, {"table", "users"}}},
+	} {
+		s.Add(r)
+	}
+
+	msgs, closed := received(ch)
+	if closed || len(msgs) != 2 || msgs[0] != "match" || msgs[1] != "match too" {
+		t.Fatalf("got %q (closed %v), want the 2 matching records", msgs, closed)
+	}
+	if s.Len() != 5 {
+		t.Errorf("the store kept %d records, want 5", s.Len())
+	}
+}
+
+func TestMemStoreSlowSubscriber(t *testing.T) {
+	s := NewMemStore(10)
+	drop, cancelDrop := s.Subscribe(Query{}, SubscribeOptions{Buffer: 2})
+	defer cancelDrop()
+	disconnect, cancelDisconnect := s.Subscribe(Query{}, SubscribeOptions{Buffer: 2, Policy: DisconnectSubscriber})
+	defer cancelDisconnect()
+
+	for _, msg := range []string{"a", "b", "c"} {
+		s.Add(Record{Message: msg})
+	}
+
+	msgs, closed := received(drop)
+	if closed || len(msgs) != 2 || msgs[0] != "a" || msgs[1] != "b" {
+		t.Errorf("DropRecords: got %q (closed %v), want a and b", msgs, closed)
+	}
+	s.Add(Record{Message: "d"})
+	if msgs, _ := received(drop); len(msgs) != 1 || msgs[0] != "d" {
+		t.Errorf("DropRecords: got %q after reading, want d", msgs)
+	}
+
+	msgs, closed = received(disconnect)
+	if !closed || len(msgs) != 2 {
+		t.Errorf("DisconnectSubscriber: got %q (closed %v), want a, b then a closed channel", msgs, closed)
+	}
+}
+
+func TestMemStoreCancel(t *testing.T) {
+	s := NewMemStore(10)
+	ch, cancel := s.Subscribe(Query{})
+	cancel()
+	cancel()
+	if _, closed := received(ch); !closed {
+		t.Fatal("the channel is open after cancel")
+	}
+	s.Add(Record{Message: "after"})
+
+	// cancel after a disconnection does not close the channel twice
+	ch, cancel = s.Subscribe(Query{}, SubscribeOptions{Buffer: 1, Policy: DisconnectSubscriber})
+	s.Add(Record{Message: "a"})
+	s.Add(Record{Message: "b"})
+	cancel()
+	if _, closed := received(ch); !closed {
+		t.Fatal("the channel is open after the disconnection")
+	}
+}
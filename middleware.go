@@ -0,0 +1,205 @@
+package lg
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-ID"
+	defaultAccessMessage   = "request"
+)
+
+// HTTPOptions configures HTTPMiddleware.
+type HTTPOptions struct {
+	// RequestIDHeader is the header holding the ID of a request. An ID is
+	// generated for the requests without one, and the ID is set on the
+	// response. The default is "X-Request-ID".
+	RequestIDHeader string
+	// SkipPaths are the paths of the requests not logged, like "/health".
+	SkipPaths []string
+	// Level returns the level of the record of a response status. The default
+	// is ErrorLevel for 5xx, WarnLevel for 4xx and InfoLevel otherwise.
+	Level func(status int) Level
+	// Message is the message of the records. The default is "request".
+	Message string
+}
+
+// HTTPMiddleware returns a middleware logging the requests with logger, the
+// default logger when nil: their method, path, status, bytes written,
+// latency, remote address, user agent and request ID.
+//
+// The handlers get a child logger of logger in the request context, with the
+// request_id, method and path fields, see FromContext. Panics are not
+// recovered, see RecoverMiddleware.
+func HTTPMiddleware(logger *Logger, opts ...HTTPOptions) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = Default()
+	}
+	var o HTTPOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.RequestIDHeader == "" {
+		o.RequestIDHeader = defaultRequestIDHeader
+	}
+	if o.Level == nil {
+		o.Level = statusLevel
+	}
+	if o.Message == "" {
+		o.Message = defaultAccessMessage
+	}
+	skip := make(map[string]bool, len(o.SkipPaths))
+	for _, p := range o.SkipPaths {
+		skip[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(o.RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(o.RequestIDHeader, id)
+
+			child := logger.With("request_id", id, "method", r.Method, "path", r.URL.Path)
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r.WithContext(WithContext(r.Context(), child)))
+			if skip[r.URL.Path] {
+				return
+			}
+
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			child.log(false, o.Level(status), o.Message,
+				"status", status,
+				"bytes", sw.size,
+				"latency", time.Since(start),
+				"remote", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+			)
+		})
+	}
+}
+
+// statusLevel returns the level of a response status.
+func statusLevel(status int) Level {
+	switch {
+	case status >= 500:
+		return ErrorLevel
+	case status >= 400:
+		return WarnLevel
+	default:
+		return InfoLevel
+	}
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:]) //nolint: errcheck
+	return hex.EncodeToString(b[:])
+}
+
+// RecoverMiddleware returns a handler recovering the panics of next: the
+// panic is logged with the method and path of the request, see Recover,
+// and the client gets a 500 response, or the response is aborted with
+// http.ErrAbortHandler when it was already started. With PanicExit the
+// program exits, otherwise the server keeps running.
+func (l *Logger) RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// meant to abort the response silently
+				panic(v)
+			}
+			l.mu.RLock()
+			policy := l.panicPolicy
+			l.mu.RUnlock()
+			if policy == PanicExit {
+				l.recovered(v, "method", r.Method, "path", r.URL.Path)
+				return
+			}
+			l.logPanic(ErrorLevel, v, "method", r.Method, "path", r.URL.Path)
+			if sw.status != 0 {
+				// too late for a 500, abort so the client sees a broken
+				// response rather than a complete one
+				panic(http.ErrAbortHandler)
+			}
+			http.Error(sw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// RecoverMiddleware returns a handler recovering the panics of next with
+// the default logger.
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return Default().RecoverMiddleware(next)
+}
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush lets the handlers stream through the writer.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets the handlers take over the connection, like websocket
+// upgraders. It returns http.ErrNotSupported when the underlying writer
+// cannot be hijacked. The response of a hijacked connection is logged with
+// the 101 status.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,177 @@
+package lg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for the handlers of test servers.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+// records decodes the JSON records written to b.
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var records []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b.b.Bytes()))
+	for dec.More() {
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, m)
+	}
+	return records
+}
+
+func TestStatusWriterHijack(t *testing.T) {
+	var out syncBuffer
+	l := NewWithOptions(&out, Options{Formatter: JSONFormatter})
+	done := make(chan struct{})
+	h := HTTPMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n") //nolint: errcheck
+		rw.Flush()                                                                                         //nolint: errcheck
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")) //nolint: errcheck
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want 101", resp.StatusCode)
+	}
+	<-done
+
+	records := out.records(t)
+	if len(records) != 1 || records[0]["status"] != float64(http.StatusSwitchingProtocols) {
+		t.Fatalf("got records %v, want one with status 101", records)
+	}
+}
+
+func TestStatusWriterHijackNotSupported(t *testing.T) {
+	var got error
+	h := HTTPMiddleware(NewWithOptions(&syncBuffer{}, Options{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, got = w.(http.Hijacker).Hijack()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !errors.Is(got, http.ErrNotSupported) {
+		t.Fatalf("got %v, want %v", got, http.ErrNotSupported)
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var out syncBuffer
+	l := NewWithOptions(&out, Options{Formatter: JSONFormatter})
+	h := HTTPMiddleware(l, HTTPOptions{SkipPaths: []string{"/health"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == Default() {
+			t.Error("no child logger in the request context")
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing")) //nolint: errcheck
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(rec, req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	if rec.Header().Get("X-Request-ID") != "abc" {
+		t.Errorf("the request ID is not set on the response")
+	}
+	records := out.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	for k, want := range map[string]any{
+		"level":      "warn",
+		"request_id": "abc",
+		"method":     "GET",
+		"path":       "/users",
+		"status":     float64(http.StatusNotFound),
+		"bytes":      float64(len("missing")),
+	} {
+		if got := records[0][k]; got != want {
+			t.Errorf("%s = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	var out syncBuffer
+	l := NewWithOptions(&out, Options{Formatter: JSONFormatter})
+	srv := httptest.NewServer(l.RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/late" {
+			w.Write([]byte("partial")) //nolint: errcheck
+			w.(http.Flusher).Flush()
+		}
+		panic("boom")
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/early")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || string(body) != "Internal Server Error\n" {
+		t.Errorf("got %d %q, want a 500 before the response started", resp.StatusCode, body)
+	}
+
+	// too late for a 500, the response is cut short
+	resp, err = http.Get(srv.URL + "/late")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil {
+		t.Errorf("got the complete response %q, want an aborted one", body)
+	}
+
+	records := out.records(t)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	for i, path := range []string{"/early", "/late"} {
+		if r := records[i]; r["panic"] != "boom" || r["path"] != path || r["level"] != "error" {
+			t.Errorf("record %d is %v, want the panic of %s", i, r, path)
+		}
+	}
+}
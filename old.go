@@ -2,6 +2,8 @@ package lg
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 const (
@@ -15,6 +17,10 @@ const (
 )
 
 func Printfs(pattern string, anything ...interface{}) {
+	Default().printfs(pattern, anything...)
+}
+
+func (l *Logger) printfs(pattern string, anything ...interface{}) {
 	var colorCode string
 	var colorUsed = true
 	switch pattern[:2] {
@@ -37,34 +43,38 @@ func Printfs(pattern string, anything ...interface{}) {
 		pattern = pattern[2:]
 	}
 	msg := fmt.Sprintf(pattern, anything...)
-	if saveMem {
-		ss.Add(msg)
-	}
-	if usePub && pub != nil {
-		pfx := ""
-		switch colorCode {
-		case "30":
-			pfx = "COMM "
-		case "31":
-			pfx = "ERRO "
-		case "32":
-			pfx = "INFO "
-		case "33":
-			pfx = "WARN "
-		case "34":
-			pfx = "DEBU "
-		case "35":
-			pfx = "FATA "
-		}
-		pub.Publish(topicPub, map[string]any{
-			"log": pfx + msg,
-		})
+	r := &Record{
+		Time:    l.timeFunc(time.Now()),
+		Level:   printfsLevel(colorCode),
+		Message: strings.TrimSuffix(msg, "\n"),
 	}
+	l.sinks.mem.Add(*r)
+	l.publish(r, true)
 	if colorUsed {
 		msg = "\033[1;" + colorCode + "m" + msg + "\033[0m"
 	}
-	df := Default()
-	df.mu.Lock()
-	defer df.mu.Unlock()
-	fmt.Fprint(df.w, msg)
+	l.mu.Lock()
+	err := l.writeOutput([]byte(msg))
+	l.mu.Unlock()
+	if err != nil {
+		l.reportError(err)
+	}
+}
+
+// printfsLevel returns the level matching a Printfs color code.
+func printfsLevel(colorCode string) Level {
+	switch colorCode {
+	case "31":
+		return ErrorLevel
+	case "32":
+		return InfoLevel
+	case "33":
+		return WarnLevel
+	case "34":
+		return DebugLevel
+	case "35":
+		return FatalLevel
+	default:
+		return NoLevel
+	}
 }
@@ -2,7 +2,10 @@ package lg
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // DefaultTimeFormat is the default time format.
@@ -36,6 +39,69 @@ func LongCallerFormatter(file string, line int, _ string) string {
 	return fmt.Sprintf("%s:%d", file, line)
 }
 
+// KeyTransform rewrites the keys of the fields of the records.
+type KeyTransform func(string) string
+
+// SnakeCaseKeys is a key transform that returns keys in snake_case, like user_id.
+func SnakeCaseKeys(key string) string {
+	words := keyWords(key)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, "_")
+}
+
+// CamelCaseKeys is a key transform that returns keys in camelCase, like userId.
+func CamelCaseKeys(key string) string {
+	words := keyWords(key)
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			r, n := utf8.DecodeRuneInString(w)
+			w = string(unicode.ToUpper(r)) + w[n:]
+		}
+		words[i] = w
+	}
+	return strings.Join(words, "")
+}
+
+// LowerCaseKeys is a key transform that returns keys in lowercase.
+func LowerCaseKeys(key string) string {
+	return strings.ToLower(key)
+}
+
+// keyWords splits key into words, at the characters other than letters and
+// digits and at case changes: "HTTPServer_id" is HTTP, Server and id.
+func keyWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		split, skip := i == len(runes), false
+		if !split {
+			r := runes[i]
+			switch {
+			case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+				split, skip = true, true
+			case i > start && unicode.IsUpper(r):
+				prev := runes[i-1]
+				split = !unicode.IsUpper(prev) ||
+					i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			}
+		}
+		if split {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+			if skip {
+				start++
+			}
+		}
+	}
+	return words
+}
+
 // Options is the options for the logger.
 type Options struct {
 	// TimeFunction is the time function for the logger. The default is time.Now.
@@ -58,5 +124,71 @@ type Options struct {
 	Fields []any
 	// Formatter is the formatter for the logger. The default is TextFormatter.
 	Formatter Formatter
-	isdef     bool
+	// JSONProfile is the schema of the JSON formatter. The default is JSONProfileDefault.
+	JSONProfile JSONProfile
+	// Keys are the keys of the built-in fields. The default is the package
+	// keys, like TimestampKey.
+	Keys Keys
+	// KeyTransform rewrites the keys of the fields, like SnakeCaseKeys. The
+	// default is none.
+	KeyTransform KeyTransform
+	// DurationFormat is how time.Duration values are encoded. The default is DurationString.
+	DurationFormat DurationFormat
+	// BytesFormat is how byte slices are encoded. The default is BytesHex.
+	BytesFormat BytesFormat
+	// ErrorFormat is how error values are encoded. The default is ErrorMessage.
+	ErrorFormat ErrorFormat
+	// ErrorStack adds the stack of the logging goroutine to the records at
+	// ErrorLevel and above, under the "stack" field. The default is false.
+	ErrorStack bool
+	// PanicPolicy is what happens once a panic recovered by Recover, Go or
+	// RecoverMiddleware is logged. The default is PanicContinue.
+	PanicPolicy PanicPolicy
+	// ANSIPolicy is how escape sequences found in messages and values are handled
+	// by the text formatter. The default is ANSIEscape.
+	ANSIPolicy ANSIPolicy
+	// MultilineBlock renders multi-line values as an indented block under the line
+	// instead of escaping their newlines. The default is false.
+	MultilineBlock bool
+	// Layout is the line layout of the text formatter. The default is LayoutPlain.
+	Layout TextLayout
+	// Pretty configures LayoutPretty.
+	Pretty PrettyOptions
+	// Template is the line layout template of the text formatter, like
+	// "{time} {level:-5} [{caller}] {prefix}: {msg} {fields}". It takes precedence
+	// over Layout. See SetTemplate for the syntax. The default is no template.
+	Template string
+	// Tree configures the rendering of large nested values as a tree in the text formatter.
+	Tree TreeOptions
+	// Syslog configures SyslogFormatter.
+	Syslog SyslogOptions
+	// Journal configures JournalFormatter.
+	Journal JournalOptions
+	// GELF configures GELFFormatter.
+	GELF GELFOptions
+	// ErrorHandler receives the internal errors of the logger, like a key that is
+	// not a string. The default is to ignore them.
+	ErrorHandler func(error)
+	// FallbackToStderr writes the entries the output fails to write to stderr.
+	// Write errors are counted in Stats and passed to ErrorHandler either way.
+	// The default is false.
+	FallbackToStderr bool
+	// Strict makes misuses of the logger, like a key that is not a string or a
+	// key without value, panic instead of being rendered with the BadKey and
+	// MissingValue placeholders. Meant for tests. The default is false.
+	Strict bool
+	// MemLogs is the number of entries kept in memory, see GetLogs. The default is
+	// none, or 20 for the default logger.
+	MemLogs int
+	// Publisher receives the records, under PublishTopic. The default is no publisher.
+	Publisher Publisher
+	// PublishTopic is the topic records are published under, see UsePublisher.
+	PublishTopic string
+	// PublisherOptions configures the publishing. The default is to publish
+	// the records written by the logger.
+	PublisherOptions *PublisherOptions
+	// DuplicateKeys is the policy for keys appearing more than once in the JSON output.
+	// The default is DuplicateKeysLastWins.
+	DuplicateKeys DuplicateKeyPolicy
+	isdef         bool
 }
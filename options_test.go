@@ -0,0 +1,31 @@
+package lg
+
+import "testing"
+
+func TestKeyTransforms(t *testing.T) {
+	for _, tt := range []struct {
+		key, snake, camel, lower string
+	}{
+		{"user_id", "user_id", "userId", "user_id"},
+		{"userID", "user_id", "userId", "userid"},
+		{"UserId", "user_id", "userId", "userid"},
+		{"HTTPServer_id", "http_server_id", "httpServerId", "httpserver_id"},
+		{"request-ID.header", "request_id_header", "requestIdHeader", "request-id.header"},
+		{"ipv4Addr", "ipv4_addr", "ipv4Addr", "ipv4addr"},
+		{"user_élan", "user_élan", "userÉlan", "user_élan"},
+		{"ÉtatCivil", "état_civil", "étatCivil", "étatcivil"},
+		{"ÜBERSize", "über_size", "überSize", "übersize"},
+		{"__x__", "x", "x", "__x__"},
+		{"", "", "", ""},
+	} {
+		if got := SnakeCaseKeys(tt.key); got != tt.snake {
+			t.Errorf("SnakeCaseKeys(%q) = %q, want %q", tt.key, got, tt.snake)
+		}
+		if got := CamelCaseKeys(tt.key); got != tt.camel {
+			t.Errorf("CamelCaseKeys(%q) = %q, want %q", tt.key, got, tt.camel)
+		}
+		if got := LowerCaseKeys(tt.key); got != tt.lower {
+			t.Errorf("LowerCaseKeys(%q) = %q, want %q", tt.key, got, tt.lower)
+		}
+	}
+}
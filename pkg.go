@@ -41,23 +41,56 @@ func New(w io.Writer) *Logger {
 // NewWithOptions returns a new logger using the provided options.
 func NewWithOptions(w io.Writer, o Options) *Logger {
 	l := &Logger{
-		b:               bytes.Buffer{},
-		mu:              &sync.RWMutex{},
-		helpers:         &sync.Map{},
-		level:           int32(o.Level),
-		reportTimestamp: o.ReportTimestamp,
-		reportCaller:    o.ReportCaller,
-		prefix:          o.Prefix,
-		timeFunc:        o.TimeFunction,
-		timeFormat:      o.TimeFormat,
-		formatter:       o.Formatter,
-		fields:          o.Fields,
-		callerFormatter: o.CallerFormatter,
-		callerOffset:    o.CallerOffset,
+		b:                bytes.Buffer{},
+		mu:               &sync.RWMutex{},
+		inErrorHandler:   new(uint32),
+		helpers:          &sync.Map{},
+		stats:            &stats{},
+		sinks:            &sinks{},
+		level:            int32(o.Level),
+		reportTimestamp:  o.ReportTimestamp,
+		reportCaller:     o.ReportCaller,
+		prefix:           o.Prefix,
+		timeFunc:         o.TimeFunction,
+		timeFormat:       o.TimeFormat,
+		formatter:        o.Formatter,
+		jsonProfile:      o.JSONProfile,
+		keys:             o.Keys.withDefaults(),
+		keyTransform:     o.KeyTransform,
+		duplicateKeys:    o.DuplicateKeys,
+		durationFormat:   o.DurationFormat,
+		bytesFormat:      o.BytesFormat,
+		errorFormat:      o.ErrorFormat,
+		errorStack:       o.ErrorStack,
+		panicPolicy:      o.PanicPolicy,
+		ansiPolicy:       o.ANSIPolicy,
+		multilineBlock:   o.MultilineBlock,
+		layout:           o.Layout,
+		pretty:           o.Pretty,
+		tree:             o.Tree,
+		errorHandler:     o.ErrorHandler,
+		strict:           o.Strict,
+		fallbackToStderr: o.FallbackToStderr,
+		fields:           o.Fields,
+		callerFormatter:  o.CallerFormatter,
+		callerOffset:     o.CallerOffset,
 	}
 
 	l.SetOutput(w)
 	l.SetLevel(Level(l.level))
+	l.SetTemplate(o.Template)
+	l.SetSyslogOptions(o.Syslog)
+	l.SetJournalOptions(o.Journal)
+	l.SetGELFOptions(o.GELF)
+	if o.isdef && o.MemLogs == 0 {
+		o.MemLogs = defaultMemLogs
+	}
+	l.sinks.mem = NewMemStore(o.MemLogs)
+	if o.PublisherOptions != nil {
+		l.UsePublisher(o.Publisher, o.PublishTopic, *o.PublisherOptions)
+	} else {
+		l.UsePublisher(o.Publisher, o.PublishTopic)
+	}
 
 	if l.callerFormatter == nil {
 		l.callerFormatter = ShortCallerFormatter
@@ -114,6 +147,118 @@ func SetFormatter(f Formatter) {
 	Default().SetFormatter(f)
 }
 
+// SetDuplicateKeys sets the duplicate key policy for the default logger.
+func SetDuplicateKeys(policy DuplicateKeyPolicy) {
+	Default().SetDuplicateKeys(policy)
+}
+
+// SetDurationFormat sets how time.Duration values are encoded for the default logger.
+func SetDurationFormat(format DurationFormat) {
+	Default().SetDurationFormat(format)
+}
+
+// SetBytesFormat sets how byte slices are encoded for the default logger.
+func SetBytesFormat(format BytesFormat) {
+	Default().SetBytesFormat(format)
+}
+
+// SetJSONProfile sets the schema of the JSON formatter for the default logger.
+func SetJSONProfile(profile JSONProfile) {
+	Default().SetJSONProfile(profile)
+}
+
+// SetKeys sets the keys of the built-in fields for the default logger.
+func SetKeys(keys Keys) {
+	Default().SetKeys(keys)
+}
+
+// SetKeyTransform sets how the keys of the fields are rewritten for the default logger.
+func SetKeyTransform(f KeyTransform) {
+	Default().SetKeyTransform(f)
+}
+
+// SetErrorFormat sets how error values are encoded for the default logger.
+func SetErrorFormat(format ErrorFormat) {
+	Default().SetErrorFormat(format)
+}
+
+// SetErrorStack sets whether the records of the default logger at ErrorLevel
+// and above get the stack of the logging goroutine.
+func SetErrorStack(stack bool) {
+	Default().SetErrorStack(stack)
+}
+
+// SetPanicPolicy sets what happens once a panic recovered with the default logger is logged.
+func SetPanicPolicy(policy PanicPolicy) {
+	Default().SetPanicPolicy(policy)
+}
+
+// SetANSIPolicy sets how escape sequences in user-supplied text are handled for the default logger.
+func SetANSIPolicy(policy ANSIPolicy) {
+	Default().SetANSIPolicy(policy)
+}
+
+// SetMultilineBlock sets whether multi-line values are rendered as a block for the default logger.
+func SetMultilineBlock(block bool) {
+	Default().SetMultilineBlock(block)
+}
+
+// SetLayout sets the line layout of the text formatter for the default logger.
+func SetLayout(layout TextLayout) {
+	Default().SetLayout(layout)
+}
+
+// SetPrettyOptions sets the options of LayoutPretty for the default logger.
+func SetPrettyOptions(opts PrettyOptions) {
+	Default().SetPrettyOptions(opts)
+}
+
+// SetTemplate sets the line layout template of the text formatter for the default logger.
+func SetTemplate(tmpl string) {
+	Default().SetTemplate(tmpl)
+}
+
+// SetSyslogOptions sets the header and structured data of SyslogFormatter for the default logger.
+func SetSyslogOptions(opts SyslogOptions) {
+	Default().SetSyslogOptions(opts)
+}
+
+// SetJournalOptions sets the identifier of JournalFormatter for the default logger.
+func SetJournalOptions(opts JournalOptions) {
+	Default().SetJournalOptions(opts)
+}
+
+// SetGELFOptions sets the host of GELFFormatter for the default logger.
+func SetGELFOptions(opts GELFOptions) {
+	Default().SetGELFOptions(opts)
+}
+
+// SetTreeOptions sets how large nested values are rendered as a tree for the default logger.
+func SetTreeOptions(opts TreeOptions) {
+	Default().SetTreeOptions(opts)
+}
+
+// SetErrorHandler sets the function receiving the internal errors of the default logger.
+func SetErrorHandler(h func(error)) {
+	Default().SetErrorHandler(h)
+}
+
+// SetStrict sets whether misuses of the default logger panic.
+func SetStrict(strict bool) {
+	Default().SetStrict(strict)
+}
+
+// SetFallbackToStderr sets whether entries the output fails to write are
+// written to stderr instead, for the default logger.
+func SetFallbackToStderr(fallback bool) {
+	Default().SetFallbackToStderr(fallback)
+}
+
+// GetStats returns the counters of the default logger activity.
+func GetStats() Stats {
+	return Default().Stats()
+}
+
 // SetCallerFormatter sets the caller formatter for the default logger.
 func SetCallerFormatter(f CallerFormatter) {
 	Default().SetCallerFormatter(f)
@@ -134,6 +279,12 @@ func GetPrefix() string {
 	return Default().GetPrefix()
 }
 
+// With returns a child logger of the default logger adding keyvals to the
+// fields of its records.
+func With(keyvals ...any) *Logger {
+	return Default().With(keyvals...)
+}
+
 // WithPrefix returns a new logger with the given prefix.
 func WithPrefix(prefix string) *Logger {
 	return Default().WithPrefix(prefix)
@@ -200,15 +351,16 @@ func CheckError(err error) bool {
 	return false
 }
 
-// Fatal logs a fatal message and exit.
+// Fatal logs a fatal message and exits, once the records waiting to be
+// published are published.
 func Fatal(msg any, keyvals ...any) {
 	llog(true, FatalLevel, msg, keyvals...)
-	os.Exit(1)
+	Default().exit()
 }
 
 func FatalC(msg any, keyvals ...any) {
 	logC(true, FatalLevel, msg, keyvals...)
-	os.Exit(1)
+	Default().exit()
 }
 
 // Print logs a message with no level.
@@ -243,7 +395,7 @@ func Errorf(format string, args ...any) {
 // Fatalf logs a fatal message with formatting and exit.
 func Fatalf(format string, args ...any) {
 	llog(true, FatalLevel, fmt.Sprintf(format, args...))
-	os.Exit(1)
+	Default().exit()
 }
 
 // StandardLog returns a standard logger from the default logger.
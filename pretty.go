@@ -0,0 +1,166 @@
+package lg
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextLayout is the line layout of the text formatter.
+type TextLayout uint8
+
+const (
+	// LayoutPlain writes each entry on a single line, parts separated by a space.
+	LayoutPlain TextLayout = iota
+	// LayoutPretty aligns the level, caller and message in columns. It is meant
+	// for local development and falls back to LayoutPlain when the output
+	// is not a terminal.
+	LayoutPretty
+)
+
+const (
+	defaultCallerWidth  = 24
+	defaultMessageWidth = 40
+	defaultTermWidth    = 80
+	// levelWidth is the width of the longest level name.
+	levelWidth = 5
+)
+
+// PrettyOptions configures LayoutPretty.
+type PrettyOptions struct {
+	// CallerWidth is the width of the caller column. The default is 24.
+	CallerWidth int
+	// MessageWidth is the width the message is padded to, so fields line up
+	// in a column. The default is 40.
+	MessageWidth int
+	// WrapFields puts the fields that do not fit in the terminal width on
+	// continuation lines, aligned with the message. The default is false.
+	WrapFields bool
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width of the terminal w, or the width advertised
+// by $COLUMNS when it cannot be queried.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if n := fileTermWidth(f); n > 0 {
+			return n
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultTermWidth
+}
+
+// textWidth returns the number of columns s takes, ignoring colors.
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// padRight pads s with spaces up to width columns.
+func padRight(s string, width int) string {
+	if n := width - textWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// padLeft pads s with leading spaces up to width columns.
+func padLeft(s string, width int) string {
+	if n := width - textWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// truncateLeft keeps the last width columns of s, marking the cut with an ellipsis.
+func truncateLeft(s string, width int) string {
+	n := textWidth(s)
+	if n <= width || width <= 0 {
+		return s
+	}
+	r := []rune(s)
+	return "…" + string(r[n-width+1:])
+}
+
+// writePretty writes the line in aligned columns.
+func (l *Logger) writePretty(t *textLine) {
+	opts := l.pretty
+	if opts.CallerWidth <= 0 {
+		opts.CallerWidth = defaultCallerWidth
+	}
+	if opts.MessageWidth <= 0 {
+		opts.MessageWidth = defaultMessageWidth
+	}
+
+	// indent is the column the message starts at.
+	indent := 0
+	if t.time != "" {
+		l.b.WriteString(colorize("gy", t.time) + " ")
+		indent += textWidth(t.time) + 1
+	}
+	lvl := padRight(t.level, levelWidth)
+	if t.level != "" {
+		lvl = colorize(t.levelColor, lvl)
+	}
+	l.b.WriteString(lvl + " ")
+	indent += levelWidth + 1
+	// the C methods report their caller whatever the setting
+	if l.reportCaller || t.caller != "" {
+		caller := padRight(truncateLeft(t.caller, opts.CallerWidth), opts.CallerWidth)
+		l.b.WriteString(colorize("gy", caller) + " ")
+		indent += opts.CallerWidth + 1
+	}
+
+	msg := t.msg
+	if t.prefix != "" {
+		msg = colorize("gy", t.prefix+":") + " " + msg
+	}
+	l.b.WriteString(msg)
+	if len(t.fields) == 0 {
+		l.b.WriteByte('\n')
+		return
+	}
+	msgWidth := textWidth(t.msg)
+	if t.prefix != "" {
+		msgWidth += textWidth(t.prefix) + 2
+	}
+	if msgWidth < opts.MessageWidth {
+		l.b.WriteString(strings.Repeat(" ", opts.MessageWidth-msgWidth))
+		msgWidth = opts.MessageWidth
+	}
+	l.b.WriteByte(' ')
+	col := indent + msgWidth + 1
+
+	width := terminalWidth(l.w)
+	for i, f := range t.fields {
+		kv := f.Key + separator + f.Value.(string)
+		if i > 0 {
+			if opts.WrapFields && col+1+textWidth(kv) > width {
+				l.b.WriteString("\n" + strings.Repeat(" ", indent))
+				col = indent
+			} else {
+				l.b.WriteByte(' ')
+				col++
+			}
+		}
+		l.b.WriteString(colorize("gy", kv))
+		col += textWidth(kv)
+	}
+	l.b.WriteByte('\n')
+}
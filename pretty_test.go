@@ -0,0 +1,58 @@
+package lg
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newPrettyTestLogger returns a logger writing the pretty layout to b, as
+// to a terminal.
+func newPrettyTestLogger(b *bytes.Buffer, o Options) *Logger {
+	o.Layout = LayoutPretty
+	o.CallerFormatter = func(string, int, string) string { return "main.go:12" }
+	l := NewWithOptions(b, o)
+	l.isTerminal = true
+	return l
+}
+
+func TestPrettyLayout(t *testing.T) {
+	var b bytes.Buffer
+	l := newPrettyTestLogger(&b, Options{
+		ReportCaller: true,
+		Pretty:       PrettyOptions{CallerWidth: 12, MessageWidth: 10},
+	})
+	l.Info("hello", "a", 1)
+	l.Warn("hi")
+
+	want := "INFO  main.go:12   hello      a=1\n" +
+		"WARN  main.go:12   hi\n"
+	if got := uncolored(&b); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrettyLayoutForcedCaller(t *testing.T) {
+	var b bytes.Buffer
+	l := newPrettyTestLogger(&b, Options{Pretty: PrettyOptions{CallerWidth: 12}})
+	l.Info("no caller")
+	l.ErrorC("with caller")
+
+	want := "INFO  no caller\n" +
+		"ERROR main.go:12   with caller\n"
+	if got := uncolored(&b); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrettyLayoutWrapFields(t *testing.T) {
+	t.Setenv("COLUMNS", "30")
+	var b bytes.Buffer
+	l := newPrettyTestLogger(&b, Options{Pretty: PrettyOptions{MessageWidth: 5, WrapFields: true}})
+	l.Info("hi", "first", "aaaaaa", "second", "bbbbbb")
+
+	want := "INFO  hi    first=aaaaaa\n" +
+		"      second=bbbbbb\n"
+	if got := uncolored(&b); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
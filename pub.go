@@ -1,28 +1,190 @@
 package lg
 
-var (
-	ss       = NewLimitedSlice[string](20)
-	pub      Publisher
-	topicPub = ""
-	usePub   = false
-	saveMem  = true
+import (
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// defaultMemLogs is the number of entries the default logger keeps in memory.
+const defaultMemLogs = 20
+
+// Publisher receives the records of a logger, see UsePublisher.
+//
+// data holds the keys "time" (time.Time), "level", "prefix", "caller",
+// "msg" (strings) and "fields" (map[string]any).
 type Publisher interface {
 	Publish(topic string, data map[string]any)
 }
 
+// PublisherOptions configures the publishing of records.
+type PublisherOptions struct {
+	// Level is the minimum level published, independently of the logger level.
+	// The default is InfoLevel.
+	Level Level
+
+	// Async publishes the records from a background goroutine, in batches,
+	// so a slow publisher does not slow down logging. Call Close to publish
+	// the pending records before exiting. The default is false.
+	Async bool
+	// QueueSize is the number of records waiting to be published, beyond
+	// which new records are dropped. The default is 1024.
+	QueueSize int
+	// BatchSize is the number of records published at once. The default is 100.
+	BatchSize int
+	// FlushInterval is the longest a record waits for its batch to be full.
+	// The default is 1s.
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a failed batch is retried.
+	// The default is 0.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// following one. The default is 100ms.
+	RetryBackoff time.Duration
+	// OnError receives the publishing errors. The default is the logger ErrorHandler.
+	OnError func(error)
+}
+
+// pubConfig is the publishing configuration of a logger.
+type pubConfig struct {
+	p     Publisher
+	topic string
+	// hasLevel is false when records are published as they are written.
+	hasLevel bool
+	level    Level
+	// async is set when publishing asynchronously.
+	async *asyncPublisher
+}
+
+// sinks are the destinations of a logger entries besides its output.
+// They can be reconfigured while logging.
+type sinks struct {
+	// mem is never replaced, so its subscriptions outlive SaveToMem calls.
+	mem *MemStore
+	pub atomic.Pointer[pubConfig]
+}
+
+// SaveToMem keeps the last nbLogs records in memory, see GetLogs.
+// The newest records already kept are preserved. A nbLogs of 0 or
+// less stops keeping records, subscriptions still receive them.
+func (l *Logger) SaveToMem(nbLogs int) {
+	l.sinks.mem.Resize(nbLogs)
+}
+
+// UsePublisher publishes records to publisher. A nil publisher stops publishing.
+//
+// topic can reference the record level and prefix, as in "logs.{level}"
+// or "logs.{prefix}". The level of records without level is "none".
+//
+// Without options, the records written by the logger are published. Otherwise,
+// records are published from the options level, whatever the logger level.
+func (l *Logger) UsePublisher(publisher Publisher, topic string, opts ...PublisherOptions) {
+	if publisher == nil {
+		l.Close() //nolint: errcheck
+		return
+	}
+	c := &pubConfig{p: publisher, topic: topic}
+	if len(opts) > 0 {
+		c.hasLevel = true
+		c.level = opts[0].Level
+		if opts[0].Async {
+			c.async = newAsyncPublisher(l, publisher, opts[0])
+		}
+	}
+	if old := l.sinks.pub.Swap(c); old != nil && old.async != nil {
+		old.async.close()
+	}
+}
+
+// GetLogs returns the memory store of the logger. It is empty when
+// SaveToMem is not used.
+func (l *Logger) GetLogs() *MemStore {
+	return l.sinks.mem
+}
+
+// publishes reports whether records of the given level are published
+// regardless of the logger level.
+func (l *Logger) publishes(level Level) bool {
+	c := l.sinks.pub.Load()
+	return c != nil && c.hasLevel && level >= c.level
+}
+
+// publish passes r to the publisher, if any and if its level allows it.
+// written reports whether r was written to the logger output.
+func (l *Logger) publish(r *Record, written bool) {
+	c := l.sinks.pub.Load()
+	if c == nil {
+		return
+	}
+	if c.hasLevel && r.Level < c.level || !c.hasLevel && !written {
+		return
+	}
+	if c.async != nil {
+		c.async.enqueue(pubItem{topic: c.topicOf(r), data: recordData(r)})
+		return
+	}
+	c.p.Publish(c.topicOf(r), recordData(r))
+	l.stats.published.Add(1)
+}
+
+// topicOf returns the topic of r.
+func (c *pubConfig) topicOf(r *Record) string {
+	if !strings.Contains(c.topic, "{") {
+		return c.topic
+	}
+	lvl := r.Level.String()
+	if lvl == "" {
+		lvl = "none"
+	}
+	return strings.NewReplacer("{level}", lvl, "{prefix}", r.Prefix).Replace(c.topic)
+}
+
+// recordData returns r as published.
+func recordData(r *Record) map[string]any {
+	return map[string]any{
+		"time":   r.Time,
+		"level":  r.Level.String(),
+		"prefix": r.Prefix,
+		"caller": r.Caller,
+		"msg":    r.Message,
+		"fields": fieldsMap(r.Fields),
+	}
+}
+
+// fieldsMap converts encoded fields to maps, as expected by most encoders.
+func fieldsMap(fields []Field) map[string]any {
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		m[f.Key] = plainValue(f.Value)
+	}
+	return m
+}
+
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case []Field:
+		return fieldsMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = plainValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// SaveToMem keeps the last nbLogs entries of the default logger in memory.
 func SaveToMem(nbLogs int) {
-	saveMem = true
-	ss = NewLimitedSlice[string](nbLogs)
+	Default().SaveToMem(nbLogs)
 }
 
-func UsePublisher(publisher Publisher, topic string) {
-	usePub = true
-	pub = publisher
-	topicPub = topic
+// UsePublisher publishes the records of the default logger to publisher.
+func UsePublisher(publisher Publisher, topic string, opts ...PublisherOptions) {
+	Default().UsePublisher(publisher, topic, opts...)
 }
 
-func GetLogs() *LimitedSlice[string] {
-	return ss
+// GetLogs returns the records of the default logger kept in memory.
+func GetLogs() *MemStore {
+	return Default().GetLogs()
 }
@@ -0,0 +1,199 @@
+package lg
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueueSize     = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultRetryBackoff  = 100 * time.Millisecond
+)
+
+// BatchPublisher is a Publisher able to publish several records at once.
+// Its errors are retried, see PublisherOptions.MaxRetries. It is only
+// used by asynchronous publishing.
+type BatchPublisher interface {
+	Publisher
+	PublishBatch(topic string, batch []map[string]any) error
+}
+
+// pubItem is a record waiting to be published.
+type pubItem struct {
+	topic string
+	data  map[string]any
+}
+
+// asyncPublisher publishes records from a background goroutine, in batches.
+type asyncPublisher struct {
+	l     *Logger
+	p     Publisher
+	opts  PublisherOptions
+	queue chan pubItem
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncPublisher(l *Logger, p Publisher, opts PublisherOptions) *asyncPublisher {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	a := &asyncPublisher{
+		l:     l,
+		p:     p,
+		opts:  opts,
+		queue: make(chan pubItem, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// enqueue queues a record, dropping it when the queue is full or closed.
+func (a *asyncPublisher) enqueue(it pubItem) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		select {
+		case a.queue <- it:
+			return
+		default:
+		}
+	}
+	a.l.stats.publishDropped.Add(1)
+}
+
+// close publishes the queued records and stops the goroutine.
+func (a *asyncPublisher) close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+func (a *asyncPublisher) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]pubItem, 0, a.opts.BatchSize)
+	for {
+		select {
+		case it, ok := <-a.queue:
+			if !ok {
+				a.flush(batch)
+				return
+			}
+			batch = append(batch, it)
+			if len(batch) < a.opts.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		a.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush publishes batch, grouping the records by topic.
+func (a *asyncPublisher) flush(batch []pubItem) {
+	if len(batch) == 0 {
+		return
+	}
+	var topics []string
+	byTopic := make(map[string][]map[string]any)
+	for _, it := range batch {
+		if _, ok := byTopic[it.topic]; !ok {
+			topics = append(topics, it.topic)
+		}
+		byTopic[it.topic] = append(byTopic[it.topic], it.data)
+	}
+	for _, topic := range topics {
+		data := byTopic[topic]
+		err := a.retry(func() error { return a.publish(topic, data) })
+		if err != nil {
+			a.l.stats.publishErrors.Add(1)
+			a.l.stats.publishDropped.Add(uint64(len(data)))
+			a.reportError(fmt.Errorf("publish %d records to %q: %w", len(data), topic, err))
+			continue
+		}
+		a.l.stats.published.Add(uint64(len(data)))
+	}
+}
+
+// retry calls fn until it succeeds, at most MaxRetries times after the first
+// call, doubling the wait between calls.
+func (a *asyncPublisher) retry(fn func() error) error {
+	backoff := a.opts.RetryBackoff
+	err := fn()
+	for i := 0; err != nil && i < a.opts.MaxRetries; i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
+
+// publish sends records to the publisher, turning its panics into errors.
+func (a *asyncPublisher) publish(topic string, data []map[string]any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("publisher panic: %v", r)
+		}
+	}()
+	if bp, ok := a.p.(BatchPublisher); ok {
+		return bp.PublishBatch(topic, data)
+	}
+	for _, d := range data {
+		a.p.Publish(topic, d)
+	}
+	return nil
+}
+
+func (a *asyncPublisher) reportError(err error) {
+	if a.opts.OnError != nil {
+		a.opts.OnError(err)
+		return
+	}
+	a.l.reportError(err)
+}
+
+// Close publishes the records waiting to be published and stops publishing.
+// It should be called before the program exits when publishing asynchronously.
+func (l *Logger) Close() error {
+	if c := l.sinks.pub.Swap(nil); c != nil && c.async != nil {
+		c.async.close()
+	}
+	return nil
+}
+
+// exit publishes the records waiting to be published, then exits the
+// program with status 1.
+func (l *Logger) exit() {
+	l.Close() //nolint: errcheck
+	os.Exit(1)
+}
+
+// Close publishes the records of the default logger waiting to be published
+// and stops publishing.
+func Close() error {
+	return Default().Close()
+}
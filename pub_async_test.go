@@ -0,0 +1,249 @@
+package lg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stdoutPublisher prints the messages of the records.
+type stdoutPublisher struct{}
+
+func (stdoutPublisher) Publish(topic string, data map[string]any) {
+	os.Stdout.WriteString("published: " + data["msg"].(string) + "\n")
+}
+
+func TestFatalPublishesQueuedRecords(t *testing.T) {
+	if os.Getenv("LG_TEST_FATAL") == "1" {
+		l := NewWithOptions(&bytes.Buffer{}, Options{})
+		l.UsePublisher(stdoutPublisher{}, "logs", PublisherOptions{Async: true, FlushInterval: time.Hour})
+		l.Info("before")
+		l.Fatal("fatal")
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalPublishesQueuedRecords$")
+	cmd.Env = append(os.Environ(), "LG_TEST_FATAL=1")
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("got %v, want an exit status 1", err)
+	}
+	if got, want := string(out), "published: before\npublished: fatal\n"; !strings.HasSuffix(got, want) {
+		t.Fatalf("got %q, want the queued records published", got)
+	}
+}
+
+var errPublish = errors.New("publish failed")
+
+// fakeBatchPublisher records the batches it publishes, failing the first
+// calls.
+type fakeBatchPublisher struct {
+	mu      sync.Mutex
+	fails   int
+	calls   int
+	batches [][]string
+	// flushed receives the size of each batch published
+	flushed chan int
+	// gate, when set, blocks the calls until closed
+	gate chan struct{}
+	// entered receives a value when a call starts
+	entered chan struct{}
+}
+
+func newFakeBatchPublisher(fails int) *fakeBatchPublisher {
+	return &fakeBatchPublisher{fails: fails, flushed: make(chan int, 100), entered: make(chan struct{}, 100)}
+}
+
+func (p *fakeBatchPublisher) Publish(topic string, data map[string]any) {
+	p.PublishBatch(topic, []map[string]any{data}) //nolint: errcheck
+}
+
+func (p *fakeBatchPublisher) PublishBatch(topic string, batch []map[string]any) error {
+	p.entered <- struct{}{}
+	if p.gate != nil {
+		<-p.gate
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.fails > 0 {
+		p.fails--
+		return errPublish
+	}
+	msgs := make([]string, len(batch))
+	for i, d := range batch {
+		msgs[i] = d["msg"].(string)
+	}
+	p.batches = append(p.batches, msgs)
+	p.flushed <- len(batch)
+	return nil
+}
+
+func (p *fakeBatchPublisher) published() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]string(nil), p.batches...)
+}
+
+func newAsyncTestLogger(p Publisher, opts PublisherOptions) *Logger {
+	l := NewWithOptions(&syncBuffer{}, Options{})
+	opts.Async = true
+	l.UsePublisher(p, "logs", opts)
+	return l
+}
+
+func waitFlush(t *testing.T, p *fakeBatchPublisher) int {
+	t.Helper()
+	select {
+	case n := <-p.flushed:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch published")
+		return 0
+	}
+}
+
+func TestAsyncPublishBatchSize(t *testing.T) {
+	p := newFakeBatchPublisher(0)
+	l := newAsyncTestLogger(p, PublisherOptions{BatchSize: 3, FlushInterval: time.Hour})
+	for i := 0; i < 7; i++ {
+		l.Info(strconv.Itoa(i))
+	}
+	if n1, n2 := waitFlush(t, p), waitFlush(t, p); n1 != 3 || n2 != 3 {
+		t.Fatalf("got batches of %d and %d records, want 3", n1, n2)
+	}
+	l.Close()
+
+	want := [][]string{{"0", "1", "2"}, {"3", "4", "5"}, {"6"}}
+	if got := p.published(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if s := l.Stats(); s.Published != 7 || s.PublishDropped != 0 {
+		t.Errorf("got %+v, want 7 records published", s)
+	}
+}
+
+func TestAsyncPublishFlushInterval(t *testing.T) {
+	p := newFakeBatchPublisher(0)
+	l := newAsyncTestLogger(p, PublisherOptions{FlushInterval: 10 * time.Millisecond})
+	defer l.Close()
+	l.Info("a")
+	l.Info("b")
+	if n := waitFlush(t, p); n != 2 {
+		t.Fatalf("got a batch of %d records, want 2", n)
+	}
+}
+
+func TestAsyncPublishRetries(t *testing.T) {
+	p := newFakeBatchPublisher(2)
+	l := newAsyncTestLogger(p, PublisherOptions{
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnError:      func(err error) { t.Errorf("unexpected error %v", err) },
+	})
+	l.Info("a")
+	l.Close()
+
+	if p.calls != 3 || len(p.published()) != 1 {
+		t.Fatalf("got %d calls publishing %v, want 3 calls publishing the record", p.calls, p.published())
+	}
+	if s := l.Stats(); s.Published != 1 || s.PublishErrors != 0 {
+		t.Errorf("got %+v, want 1 record published", s)
+	}
+}
+
+func TestAsyncPublishErrors(t *testing.T) {
+	var onError, handler []error
+	p := newFakeBatchPublisher(10)
+	l := newAsyncTestLogger(p, PublisherOptions{
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+		OnError:      func(err error) { onError = append(onError, err) },
+	})
+	l.SetErrorHandler(func(err error) { handler = append(handler, err) })
+	l.Info("a")
+	l.Info("b")
+	l.Close()
+
+	if p.calls != 2 || len(onError) != 1 || !errors.Is(onError[0], errPublish) || len(handler) != 0 {
+		t.Fatalf("got %d calls, OnError %v and ErrorHandler %v, want 2 calls and 1 error to OnError", p.calls, onError, handler)
+	}
+	if s := l.Stats(); s.PublishErrors != 1 || s.PublishDropped != 2 || s.Published != 0 {
+		t.Errorf("got %+v, want 1 error dropping 2 records", s)
+	}
+
+	// without OnError, the errors go to the ErrorHandler
+	l = newAsyncTestLogger(newFakeBatchPublisher(10), PublisherOptions{})
+	l.SetErrorHandler(func(err error) { handler = append(handler, err) })
+	l.Info("a")
+	l.Close()
+	if len(handler) != 1 || !errors.Is(handler[0], errPublish) {
+		t.Fatalf("the ErrorHandler got %v", handler)
+	}
+}
+
+func TestAsyncPublishQueueFull(t *testing.T) {
+	p := newFakeBatchPublisher(0)
+	p.gate = make(chan struct{})
+	l := newAsyncTestLogger(p, PublisherOptions{QueueSize: 2, BatchSize: 1})
+	l.Info("0")
+	// the first record is being published, the next 2 fill the queue
+	<-p.entered
+	for i := 1; i < 5; i++ {
+		l.Info(strconv.Itoa(i))
+	}
+	if s := l.Stats(); s.PublishDropped != 2 {
+		t.Errorf("got %d records dropped, want 2", s.PublishDropped)
+	}
+	close(p.gate)
+	l.Close()
+
+	want := [][]string{{"0"}, {"1"}, {"2"}}
+	if got := p.published(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if s := l.Stats(); s.Published != 3 {
+		t.Errorf("got %d records published, want 3", s.Published)
+	}
+}
+
+func TestAsyncPublishClose(t *testing.T) {
+	p := newFakeBatchPublisher(0)
+	l := newAsyncTestLogger(p, PublisherOptions{FlushInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		l.Info(strconv.Itoa(i))
+	}
+	l.Close()
+	if got := p.published(); len(got) != 1 || len(got[0]) != 5 {
+		t.Fatalf("got %v, want the 5 queued records", got)
+	}
+	// closing again, and logging after closing, are harmless
+	l.Close()
+	l.Info("after")
+	if got := p.published(); len(got) != 1 {
+		t.Fatalf("got %v after Close", got)
+	}
+}
+
+func TestAsyncUsePublisherSwap(t *testing.T) {
+	old, next := newFakeBatchPublisher(0), newFakeBatchPublisher(0)
+	l := newAsyncTestLogger(old, PublisherOptions{FlushInterval: time.Hour})
+	l.Info("a")
+	l.Info("b")
+	l.UsePublisher(next, "logs", PublisherOptions{Async: true, FlushInterval: time.Hour})
+	// the queue of the old publisher is drained by the swap
+	if got, want := old.published(), [][]string{{"a", "b"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("the old publisher got %v, want %v", got, want)
+	}
+	l.Info("c")
+	l.Close()
+	if got, want := next.published(), [][]string{{"c"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("the new publisher got %v, want %v", got, want)
+	}
+}
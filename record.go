@@ -0,0 +1,152 @@
+package lg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a single key/value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// DuplicateKeyPolicy controls what happens when the same key
+// appears more than once in a single log entry.
+type DuplicateKeyPolicy uint8
+
+const (
+	// DuplicateKeysLastWins keeps the position of the first occurrence
+	// and the value of the last one.
+	DuplicateKeysLastWins DuplicateKeyPolicy = iota
+	// DuplicateKeysFirstWins keeps the first occurrence and drops the others.
+	DuplicateKeysFirstWins
+	// DuplicateKeysSuffix keeps every occurrence, renaming duplicates
+	// to key_1, key_2, ...
+	DuplicateKeysSuffix
+)
+
+// Record is a log entry.
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Prefix  string
+	Caller  string
+	Message string
+	// Fields holds the logger fields followed by the call fields. Their
+	// values are encoded before the record is formatted, as described
+	// by LogMarshaler, DurationFormat and BytesFormat.
+	Fields []Field
+
+	// the caller frame, for the formatters writing its parts
+	file     string
+	line     int
+	function string
+}
+
+// String returns the record as a single line of text, without timestamp.
+func (r Record) String() string {
+	var parts []string
+	if lvl := toCapLevel(r.Level.String()); lvl != "" {
+		if len(lvl) > 3 {
+			lvl = lvl[:4]
+		}
+		parts = append(parts, lvl)
+	}
+	if r.Caller != "" {
+		parts = append(parts, "["+r.Caller+"]")
+	}
+	if r.Prefix != "" {
+		parts = append(parts, r.Prefix+":")
+	}
+	if r.Message != "" {
+		parts = append(parts, r.Message)
+	}
+	for _, f := range r.Fields {
+		val := textValue(f.Value)
+		if val == "" || needsQuoting(val) {
+			val = strconv.Quote(val)
+		}
+		parts = append(parts, f.Key+separator+val)
+	}
+	return strings.Join(parts, " ")
+}
+
+const (
+	// BadKey is the key used, in lenient mode, for values found where a key was expected.
+	BadKey = "!BADKEY"
+	// MissingValue is the value used, in lenient mode, for a key without value.
+	MissingValue = "!MISSING"
+)
+
+// appendFields converts keyvals to fields, their keys rewritten by
+// transform if not nil, and appends them to dst.
+//
+// A value found where a key was expected is added under BadKey, and a
+// trailing key gets MissingValue. Both are reported to the error handler,
+// or panic in strict mode.
+func (l *Logger) appendFields(dst []Field, keyvals []any, transform KeyTransform) []Field {
+	for i := 0; i < len(keyvals); i++ {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			l.internalError(fmt.Errorf("%w: %T %v", ErrBadKey, keyvals[i], keyvals[i]))
+			dst = append(dst, Field{Key: BadKey, Value: keyvals[i]})
+			continue
+		}
+		if i+1 >= len(keyvals) {
+			l.internalError(fmt.Errorf("%w for key %q", ErrMissingValue, key))
+			dst = append(dst, Field{Key: key, Value: MissingValue})
+			break
+		}
+		i++
+		if transform != nil {
+			key = transform(key)
+		}
+		dst = append(dst, Field{Key: key, Value: keyvals[i]})
+	}
+	return dst
+}
+
+func fieldKey(k any) string {
+	switch k := k.(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	case error:
+		return k.Error()
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+// dedupFields applies the duplicate key policy to fields, preserving order.
+func dedupFields(fields []Field, policy DuplicateKeyPolicy) []Field {
+	seen := make(map[string]int, len(fields))
+	out := fields[:0:0]
+	for _, f := range fields {
+		idx, dup := seen[f.Key]
+		if !dup {
+			seen[f.Key] = len(out)
+			out = append(out, f)
+			continue
+		}
+		switch policy {
+		case DuplicateKeysFirstWins:
+		case DuplicateKeysSuffix:
+			for n := 1; ; n++ {
+				key := f.Key + "_" + strconv.Itoa(n)
+				if _, taken := seen[key]; !taken {
+					seen[key] = len(out)
+					out = append(out, Field{Key: key, Value: f.Value})
+					break
+				}
+			}
+		default:
+			out[idx].Value = f.Value
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package lg
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppendFieldsLenient(t *testing.T) {
+	var b bytes.Buffer
+	var errs []error
+	l := NewWithOptions(&b, Options{
+		Formatter:    JSONFormatter,
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	})
+	l.Info("hi", 42, "a", 1, "dangling")
+
+	want := `{"level":"info","msg":"hi","!BADKEY":42,"a":1,"dangling":"!MISSING"}` + "\n"
+	if got := b.String(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrBadKey) || !errors.Is(errs[1], ErrMissingValue) {
+		t.Fatalf("the error handler got %v, want a bad key then a missing value", errs)
+	}
+	if !strings.Contains(errs[1].Error(), `"dangling"`) {
+		t.Errorf("the missing value error %q does not name the key", errs[1])
+	}
+}
+
+func TestAppendFieldsStrict(t *testing.T) {
+	for _, keyvals := range [][]any{{42, "v"}, {"a", 1, "dangling"}} {
+		var b bytes.Buffer
+		l := NewWithOptions(&b, Options{Strict: true})
+		func() {
+			defer func() {
+				r := recover()
+				if s, ok := r.(string); !ok || !strings.HasPrefix(s, "lg: ") {
+					t.Errorf("keyvals %v: got panic %v, want a panic of the logger", keyvals, r)
+				}
+			}()
+			l.Info("hi", keyvals...)
+		}()
+		if b.Len() != 0 {
+			t.Errorf("keyvals %v: wrote %q before panicking", keyvals, b.String())
+		}
+	}
+
+	// a well formed call does not panic
+	l := NewWithOptions(&bytes.Buffer{}, Options{Strict: true})
+	l.Info("hi", "a", 1)
+}
@@ -0,0 +1,105 @@
+package lg
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PanicPolicy is what happens once a recovered panic is logged, see Recover.
+type PanicPolicy uint8
+
+const (
+	// PanicContinue keeps the program running. The panic is logged at ErrorLevel.
+	PanicContinue PanicPolicy = iota
+	// PanicRepanic panics again with the same value. The panic is logged at ErrorLevel.
+	PanicRepanic
+	// PanicExit exits the program with status 1, once the records waiting to
+	// be published are published. The panic is logged at FatalLevel.
+	PanicExit
+)
+
+// Recover recovers a panic, logs it with its value, its stack and the
+// location of the panic, then applies the panic policy of the logger.
+// It must be deferred directly:
+//
+//	defer logger.Recover()
+func (l *Logger) Recover() {
+	if v := recover(); v != nil {
+		l.recovered(v)
+	}
+}
+
+// Recover recovers a panic and logs it with the default logger.
+// It must be deferred directly.
+func Recover() {
+	if v := recover(); v != nil {
+		Default().recovered(v)
+	}
+}
+
+// Go runs fn in a new goroutine, recovering its panics, see Recover.
+func (l *Logger) Go(fn func()) {
+	go func() {
+		defer l.Recover()
+		fn()
+	}()
+}
+
+// Go runs fn in a new goroutine, recovering its panics with the default logger.
+func Go(fn func()) {
+	Default().Go(fn)
+}
+
+// recovered logs the panic v then applies the panic policy.
+func (l *Logger) recovered(v any, keyvals ...any) {
+	l.mu.RLock()
+	policy := l.panicPolicy
+	l.mu.RUnlock()
+	level := ErrorLevel
+	if policy == PanicExit {
+		level = FatalLevel
+	}
+	l.logPanic(level, v, keyvals...)
+
+	switch policy {
+	case PanicRepanic:
+		panic(v)
+	case PanicExit:
+		l.exit()
+	}
+}
+
+// logPanic logs the panic v from a deferred function, with the stack of
+// the panicking goroutine.
+func (l *Logger) logPanic(level Level, v any, keyvals ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	frames := panicFrames()
+	var frame runtime.Frame
+	if len(frames) > 0 {
+		frame = frames[0]
+	}
+	keyvals = append([]any{"panic", v, "stack", formatStack(frames)}, keyvals...)
+	l.logFrame(level, frame, true, fmt.Sprint("panic: ", v), keyvals...)
+}
+
+// panicFrames returns the stack of the panicking goroutine, from the
+// function that panicked.
+func panicFrames() []runtime.Frame {
+	pcs := make([]uintptr, maxStackDepth)
+	frames := callersFrames(pcs[:runtime.Callers(1, pcs)])
+	for i, f := range frames {
+		if f.Function != "runtime.gopanic" {
+			continue
+		}
+		frames = frames[i+1:]
+		// like runtime.panicmem, for the panics raised by the runtime
+		for len(frames) > 1 && strings.HasPrefix(frames[0].Function, "runtime.") {
+			frames = frames[1:]
+		}
+		break
+	}
+	return frames
+}
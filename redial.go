@@ -0,0 +1,81 @@
+package lg
+
+import (
+	"net"
+	"time"
+)
+
+const (
+	// dialTimeout bounds the connections of the writers, made while the
+	// logger mutex is held.
+	dialTimeout = 2 * time.Second
+	// minRedialBackoff and maxRedialBackoff bound the wait between the
+	// connections of a writer whose daemon is down.
+	minRedialBackoff = 100 * time.Millisecond
+	maxRedialBackoff = 30 * time.Second
+)
+
+// redialer is the connection of a writer to a daemon. It reconnects when a
+// write fails. Once a connection fails, it does not connect again before a
+// backoff, doubled after each failure, so that a daemon being down does not
+// slow every record down.
+//
+// Its methods must be called with the mutex of the writer held.
+type redialer struct {
+	dial func(d *net.Dialer) (net.Conn, error)
+
+	conn    net.Conn
+	err     error // error of the last connection
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// connect connects, unless a connection failed less than the backoff ago,
+// in which case it returns the error of that connection.
+func (r *redialer) connect() error {
+	if time.Now().Before(r.retryAt) {
+		return r.err
+	}
+	conn, err := r.dial(&net.Dialer{Timeout: dialTimeout})
+	if err != nil {
+		r.backoff *= 2
+		if r.backoff < minRedialBackoff {
+			r.backoff = minRedialBackoff
+		} else if r.backoff > maxRedialBackoff {
+			r.backoff = maxRedialBackoff
+		}
+		r.err, r.retryAt = err, time.Now().Add(r.backoff)
+		return err
+	}
+	r.conn, r.err, r.backoff, r.retryAt = conn, nil, 0, time.Time{}
+	return nil
+}
+
+// write calls send with the connection, connecting first when there is
+// none. When send fails, it reconnects and calls it once more.
+func (r *redialer) write(send func(conn net.Conn) error) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if r.conn == nil {
+			if err = r.connect(); err != nil {
+				return err
+			}
+		}
+		if err = send(r.conn); err == nil {
+			return nil
+		}
+		r.conn.Close() //nolint: errcheck
+		r.conn = nil
+	}
+	return err
+}
+
+// close closes the connection, if any.
+func (r *redialer) close() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
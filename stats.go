@@ -0,0 +1,41 @@
+package lg
+
+import "sync/atomic"
+
+// Stats are counters of a logger activity.
+type Stats struct {
+	// Records is the number of entries written to the output.
+	Records uint64
+	// WriteErrors is the number of entries the output failed to write.
+	WriteErrors uint64
+	// FallbackWrites is the number of failed entries written to stderr instead.
+	FallbackWrites uint64
+	// Published is the number of records published.
+	Published uint64
+	// PublishErrors is the number of failed publishing attempts, after retries.
+	PublishErrors uint64
+	// PublishDropped is the number of records not published, because the
+	// queue was full or publishing failed.
+	PublishDropped uint64
+}
+
+type stats struct {
+	records        atomic.Uint64
+	writeErrors    atomic.Uint64
+	fallbackWrites atomic.Uint64
+	published      atomic.Uint64
+	publishErrors  atomic.Uint64
+	publishDropped atomic.Uint64
+}
+
+// Stats returns the counters of the logger activity.
+func (l *Logger) Stats() Stats {
+	return Stats{
+		Records:        l.stats.records.Load(),
+		WriteErrors:    l.stats.writeErrors.Load(),
+		FallbackWrites: l.stats.fallbackWrites.Load(),
+		Published:      l.stats.published.Load(),
+		PublishErrors:  l.stats.publishErrors.Load(),
+		PublishDropped: l.stats.publishDropped.Load(),
+	}
+}
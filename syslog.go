@@ -0,0 +1,311 @@
+package lg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Facility is a syslog facility.
+type Facility uint8
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// defaultSyslogSDID is the structured data ID the fields are written under.
+const defaultSyslogSDID = "lg@32473"
+
+// SyslogOptions configures SyslogFormatter.
+type SyslogOptions struct {
+	// Facility is the facility of the messages. The default is FacilityUser,
+	// FacilityKern cannot be used.
+	Facility Facility
+	// Hostname is the host name of the messages. The default is os.Hostname.
+	Hostname string
+	// AppName is the application name of the messages. The default is the
+	// base name of the program.
+	AppName string
+	// ProcID is the process ID of the messages. The default is the process ID.
+	ProcID string
+	// MsgID is the message type of the messages (RFC 5424). The default is none.
+	MsgID string
+	// SDID is the structured data ID the prefix, caller and fields are written
+	// under (RFC 5424). The default is "lg@32473".
+	SDID string
+	// RFC3164 writes messages in the BSD format, the fields following the message
+	// as in text output, instead of RFC 5424. The default is false.
+	RFC3164 bool
+}
+
+// withDefaults returns opts with its unset fields set to their defaults.
+func (opts SyslogOptions) withDefaults() SyslogOptions {
+	if opts.Facility == FacilityKern {
+		opts.Facility = FacilityUser
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = programName()
+	}
+	if opts.ProcID == "" {
+		opts.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if opts.SDID == "" {
+		opts.SDID = defaultSyslogSDID
+	}
+	return opts
+}
+
+// programName returns the base name of the program.
+func programName() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// syslogSeverity returns the syslog severity of level.
+func syslogSeverity(level Level) int {
+	switch {
+	case level == NoLevel:
+		return 5 // notice
+	case level >= FatalLevel:
+		return 2 // critical
+	case level >= ErrorLevel:
+		return 3 // error
+	case level >= WarnLevel:
+		return 4 // warning
+	case level >= InfoLevel:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+func (l *Logger) syslogFormatter(r *Record) {
+	opts := l.syslog
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	l.b.WriteByte('<')
+	l.b.WriteString(strconv.Itoa(int(opts.Facility)*8 + syslogSeverity(r.Level)))
+	l.b.WriteByte('>')
+	if opts.RFC3164 {
+		l.b.WriteString(t.Format(time.Stamp))
+		l.b.WriteByte(' ')
+		l.b.WriteString(syslogHeader(opts.Hostname, 255))
+		l.b.WriteByte(' ')
+		l.b.WriteString(syslogHeader(opts.AppName, 32))
+		l.b.WriteString("[" + syslogHeader(opts.ProcID, 128) + "]: ")
+		l.writeSyslogText(r)
+		l.b.WriteByte('\n')
+		return
+	}
+
+	l.b.WriteString("1 ")
+	l.b.WriteString(t.Format("2006-01-02T15:04:05.000000Z07:00"))
+	for _, s := range [...]struct {
+		v   string
+		max int
+	}{{opts.Hostname, 255}, {opts.AppName, 48}, {opts.ProcID, 128}, {opts.MsgID, 32}} {
+		l.b.WriteByte(' ')
+		l.b.WriteString(syslogHeader(s.v, s.max))
+	}
+	l.b.WriteByte(' ')
+
+	keys := l.keys
+	params := make([]Field, 0, len(r.Fields)+2)
+	if r.Prefix != "" {
+		params = append(params, Field{keys.Prefix, r.Prefix})
+	}
+	if r.Caller != "" {
+		params = append(params, Field{keys.Caller, r.Caller})
+	}
+	params = append(params, r.Fields...)
+	params = dedupFields(params, l.duplicateKeys)
+	if len(params) == 0 {
+		l.b.WriteByte('-')
+	} else {
+		l.b.WriteString("[" + syslogName(opts.SDID))
+		for _, f := range params {
+			l.b.WriteString(" " + syslogName(f.Key) + `="`)
+			l.b.WriteString(syslogParamEscaper.Replace(l.escapeText(textValue(f.Value))))
+			l.b.WriteByte('"')
+		}
+		l.b.WriteByte(']')
+	}
+	if r.Message != "" {
+		l.b.WriteByte(' ')
+		l.b.WriteString(l.escapeText(r.Message))
+	}
+	l.b.WriteByte('\n')
+}
+
+// writeSyslogText writes the prefix, message and fields of r as in text output.
+func (l *Logger) writeSyslogText(r *Record) {
+	var parts []string
+	if r.Caller != "" {
+		parts = append(parts, "<"+l.escapeText(r.Caller)+">")
+	}
+	msg := l.escapeText(r.Message)
+	if r.Prefix != "" {
+		msg = l.escapeText(r.Prefix) + ": " + msg
+	}
+	if msg != "" {
+		parts = append(parts, msg)
+	}
+	for _, f := range dedupFields(r.Fields, l.duplicateKeys) {
+		parts = append(parts, l.quoteKey(f.Key)+separator+l.quoteValue(textValue(f.Value)))
+	}
+	l.b.WriteString(strings.Join(parts, " "))
+}
+
+var syslogParamEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// syslogHeader returns s as a header field: printable ASCII of at most max
+// characters, or "-" when empty.
+func syslogHeader(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// syslogName returns s as a structured data name: at most 32 printable ASCII
+// characters other than '=', ']' and '"'.
+func syslogName(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < 32; i++ {
+		switch c := s[i]; {
+		case c <= ' ' || c >= 0x7f, c == '=', c == ']', c == '"':
+			b = append(b, '_')
+		default:
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// syslogSockets are the sockets of the local syslog daemon.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriter writes the messages of SyslogFormatter to a syslog daemon,
+// one message per Write. It reconnects when a write fails, waiting longer
+// between the connections while the daemon is down.
+type SyslogWriter struct {
+	network string
+	addr    string
+
+	mu   sync.Mutex
+	conn redialer
+}
+
+// NewSyslogWriter returns a writer to the syslog daemon listening on addr.
+// The network is "unixgram", "unix", "udp" or "tcp". When it is empty, the
+// writer connects to the local daemon on its usual unix socket.
+//
+// Over stream networks the messages are framed: by a newline on unix sockets,
+// by their length on TCP (RFC 6587).
+func NewSyslogWriter(network, addr string) (*SyslogWriter, error) {
+	w := &SyslogWriter{network: network, addr: addr}
+	w.conn.dial = w.dial
+	if err := w.conn.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *SyslogWriter) dial(d *net.Dialer) (net.Conn, error) {
+	if w.network != "" {
+		conn, err := d.Dial(w.network, w.addr)
+		if err != nil {
+			return nil, fmt.Errorf("syslog: %w", err)
+		}
+		return conn, nil
+	}
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := d.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("syslog: no local syslog daemon")
+}
+
+// Write sends p as a message, its trailing newline removed.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.conn.write(func(conn net.Conn) error { return syslogSend(conn, msg) }); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// syslogSend writes msg to conn, framed for stream networks.
+func syslogSend(conn net.Conn, msg []byte) error {
+	var frame []byte
+	switch conn.LocalAddr().Network() {
+	case "tcp":
+		frame = append(strconv.AppendInt(nil, int64(len(msg)), 10), ' ')
+		frame = append(frame, msg...)
+	case "unix":
+		frame = append(append(frame, msg...), '\n')
+	default:
+		frame = msg
+	}
+	_, err := conn.Write(frame)
+	return err
+}
+
+// Close closes the connection to the daemon.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.close()
+}
@@ -0,0 +1,171 @@
+package lg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSyslogOptions = SyslogOptions{Hostname: "host", AppName: "app", ProcID: "42"}
+
+// testTime is the time of the records of the tests.
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+func newSyslogTestLogger(t *testing.T, network, addr string, opts SyslogOptions) *Logger {
+	t.Helper()
+	w, err := NewSyslogWriter(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return NewWithOptions(w, Options{
+		Formatter:    SyslogFormatter,
+		Syslog:       opts,
+		TimeFunction: func(time.Time) time.Time { return testTime },
+	})
+}
+
+func TestSyslogWriterRFC5424(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	l := newSyslogTestLogger(t, "udp", pc.LocalAddr().String(), testSyslogOptions)
+
+	l.Warn("hello", "user", `b"o]b`)
+	l.Info("")
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, want := range []string{
+		`<12>1 2024-01-02T03:04:05.000006Z host app 42 - [lg@32473 user="b\"o\]b"] hello`,
+		`<14>1 2024-01-02T03:04:05.000006Z host app 42 - -`,
+	} {
+		buf := make([]byte, 1024)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("got  %q\nwant %q", got, want)
+		}
+	}
+}
+
+func TestSyslogWriterRFC3164OverTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	opts := testSyslogOptions
+	opts.RFC3164 = true
+	opts.Facility = FacilityLocal0
+	l := newSyslogTestLogger(t, "tcp", ln.Addr().String(), opts)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	l.Error("disk full", "path", "/var")
+	l.Info("multi\nline")
+
+	// the messages are framed by their length (RFC 6587)
+	r := bufio.NewReader(conn)
+	for _, want := range []string{
+		"<131>Jan  2 03:04:05 host app[42]: disk full path=/var",
+		"<134>Jan  2 03:04:05 host app[42]: multi\\nline",
+	} {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+		if err != nil {
+			t.Fatalf("bad frame length %q", size)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(msg); got != want {
+			t.Errorf("got  %q\nwant %q", got, want)
+		}
+	}
+}
+
+func TestSyslogWriterUnixStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	l := newSyslogTestLogger(t, "unix", path, testSyslogOptions)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	l.Info("one")
+	l.Info("two")
+
+	// the messages are framed by a newline
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"one", "two"} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(line, " 42 - - "+want+"\n") {
+			t.Errorf("got %q, want a message %q", line, want)
+		}
+	}
+}
+
+func TestRedialerBackoff(t *testing.T) {
+	dials := 0
+	r := redialer{dial: func(*net.Dialer) (net.Conn, error) {
+		dials++
+		return nil, errors.New("down")
+	}}
+	send := func(net.Conn) error { return nil }
+
+	for i := 0; i < 3; i++ {
+		if err := r.write(send); err == nil {
+			t.Fatal("write succeeded without connection")
+		}
+	}
+	if dials != 1 {
+		t.Fatalf("dialed %d times during the backoff, want 1", dials)
+	}
+
+	r.retryAt = time.Now()
+	r.write(send) //nolint: errcheck
+	if dials != 2 || r.backoff != 2*minRedialBackoff {
+		t.Fatalf("dials, backoff = %d, %v, want 2, %v", dials, r.backoff, 2*minRedialBackoff)
+	}
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	r.dial = func(*net.Dialer) (net.Conn, error) { return c1, nil }
+	r.retryAt = time.Now()
+	if err := r.write(send); err != nil {
+		t.Fatal(err)
+	}
+	if r.backoff != 0 || r.conn != c1 {
+		t.Fatalf("the backoff was not reset after a connection")
+	}
+}
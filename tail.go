@@ -0,0 +1,136 @@
+package lg
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	tailBuffer    = 256
+	tailKeepAlive = 15 * time.Second
+)
+
+// TailHandler returns a handler streaming the records of the logger as
+// Server-Sent Events, each event data being a record in JSON.
+//
+// Records come from the memory store, see GetLogs, so only the records
+// written by the logger are streamed. The query parameters are:
+//
+//	level   the minimum level, like "warn"
+//	prefix  the prefix of the records
+//	q       a text the records must contain, see Record.String
+//	n       the number of kept records to send first
+//
+// For example: curl -N 'http://host/logs?level=warn&n=20'
+func (l *Logger) TailHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		q, err := parseTailQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		store := l.GetLogs()
+		// subscribe before replaying, so no record is missed in between
+		records, cancel := store.Subscribe(q, SubscribeOptions{
+			Buffer: tailBuffer,
+			Policy: DisconnectSubscriber,
+		})
+		defer cancel()
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("Connection", "keep-alive")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		var b bytes.Buffer
+		if n, _ := strconv.Atoi(r.URL.Query().Get("n")); n > 0 {
+			replay := q
+			replay.NewestFirst, replay.Limit = true, n
+			kept := store.Query(replay)
+			for i := len(kept) - 1; i >= 0; i-- {
+				writeEvent(&b, kept[i])
+			}
+		}
+		if _, err := w.Write(b.Bytes()); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(tailKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			b.Reset()
+			select {
+			case <-r.Context().Done():
+				return
+			case rec, ok := <-records:
+				if !ok {
+					// too slow to keep up
+					return
+				}
+				writeEvent(&b, rec)
+			case <-keepAlive.C:
+				b.WriteString(": keep-alive\n\n")
+			}
+			if _, err := w.Write(b.Bytes()); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// TailHandler returns a handler streaming the records of the default logger
+// as Server-Sent Events.
+func TailHandler() http.Handler {
+	return Default().TailHandler()
+}
+
+// parseTailQuery returns the filter described by the query parameters of r.
+func parseTailQuery(r *http.Request) (Query, error) {
+	params := r.URL.Query()
+	q := Query{
+		Prefix: params.Get("prefix"),
+		Text:   params.Get("q"),
+	}
+	if s := params.Get("level"); s != "" {
+		lvl, err := ParseLevel(s)
+		if err != nil {
+			return q, err
+		}
+		q.MinLevel = &lvl
+	}
+	return q, nil
+}
+
+// writeEvent writes r as a Server-Sent Event.
+func writeEvent(b *bytes.Buffer, r Record) {
+	b.WriteString("data: ")
+	writeRecordJSON(b, r)
+	b.WriteString("\n\n")
+}
+
+// writeRecordJSON writes r as a JSON object, with the same keys as published
+// records.
+func writeRecordJSON(b *bytes.Buffer, r Record) {
+	fields := []Field{
+		{"time", r.Time.Format(time.RFC3339Nano)},
+		{"level", r.Level.String()},
+		{"prefix", r.Prefix},
+		{"caller", r.Caller},
+		{"msg", r.Message},
+		{"fields", r.Fields},
+	}
+	e := jsonEncoder{b: b}
+	e.writeObject(fields)
+}
@@ -0,0 +1,97 @@
+package lg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tail connects to the tail handler of srv with the query query, and
+// returns a function reading the messages of the next events.
+func tail(t *testing.T, srv *httptest.Server, query string) func() string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got status %d and type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	r := bufio.NewReader(resp.Body)
+	return func() string {
+		t.Helper()
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var rec struct{ Msg string }
+			if err := json.Unmarshal([]byte(line[len("data: "):]), &rec); err != nil {
+				t.Fatalf("bad event %q: %v", line, err)
+			}
+			return rec.Msg
+		}
+	}
+}
+
+func TestTailHandler(t *testing.T) {
+	l := NewWithOptions(&syncBuffer{}, Options{MemLogs: 10})
+	db := l.With()
+	db.SetPrefix("db")
+	db.Info("old info")
+	db.Warn("old warn 1")
+	l.Error("old other prefix")
+	db.Error("old warn 2 needle")
+	db.Warn("old warn 3")
+
+	srv := httptest.NewServer(l.TailHandler())
+	// closed once the streams are
+	t.Cleanup(srv.Close)
+
+	// the last 2 kept records matching, oldest first, then the new ones
+	next := tail(t, srv, "level=warn&prefix=db&n=2")
+	for _, want := range []string{"old warn 2 needle", "old warn 3"} {
+		if got := next(); got != want {
+			t.Fatalf("replayed %q, want %q", got, want)
+		}
+	}
+	db.Info("live info")
+	l.Warn("live other prefix")
+	db.Warn("live warn")
+	if got := next(); got != "live warn" {
+		t.Fatalf("got %q, want the live record", got)
+	}
+
+	next = tail(t, srv, "q=needle&n=10")
+	if got := next(); got != "old warn 2 needle" {
+		t.Fatalf("got %q, want the record containing the text", got)
+	}
+	db.Info("live needle")
+	if got := next(); got != "live needle" {
+		t.Fatalf("got %q, want the live record containing the text", got)
+	}
+}
+
+func TestTailHandlerBadLevel(t *testing.T) {
+	l := NewWithOptions(&syncBuffer{}, Options{MemLogs: 10})
+	rec := httptest.NewRecorder()
+	l.TailHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?level=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,254 @@
+package lg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// templatePart is either literal text or a reference to a part of the line.
+type templatePart struct {
+	literal string
+	// name is the referenced part: time, level, caller, prefix, msg, fields
+	// or the key of a field.
+	name string
+	// width pads the part to width columns, on the left when negative.
+	width int
+	style string
+}
+
+// parseTemplate parses a text layout template, see Logger.SetTemplate for the syntax.
+func parseTemplate(tmpl string) []templatePart {
+	var (
+		parts   []templatePart
+		literal strings.Builder
+	)
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		if (c == '{' || c == '}') && i+1 < len(tmpl) && tmpl[i+1] == c {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(tmpl[i:], '}')
+		if end < 0 {
+			literal.WriteString(tmpl[i:])
+			break
+		}
+		part, ok := parseTemplatePart(tmpl[i+1 : i+end])
+		if !ok {
+			literal.WriteString(tmpl[i : i+end+1])
+		} else {
+			flush()
+			parts = append(parts, part)
+		}
+		i += end
+	}
+	flush()
+	return parts
+}
+
+func parseTemplatePart(s string) (templatePart, bool) {
+	var p templatePart
+	s, p.style, _ = strings.Cut(s, "|")
+	name, width, hasWidth := strings.Cut(s, ":")
+	p.name = strings.TrimSpace(name)
+	if p.name == "" {
+		return p, false
+	}
+	if hasWidth {
+		w, err := strconv.Atoi(strings.TrimSpace(width))
+		if err != nil {
+			return p, false
+		}
+		p.width = w
+	}
+	p.style = strings.TrimSpace(p.style)
+	switch p.style {
+	case "", "none", "level":
+	default:
+		if !isColor(p.style) {
+			// unknown styles are ignored, Color would log an error for each line
+			p.style = ""
+		}
+	}
+	return p, true
+}
+
+// writeTemplate writes the line following the logger template.
+func (l *Logger) writeTemplate(t *textLine) {
+	var used map[string]bool
+	for _, p := range l.template {
+		switch p.name {
+		case "", "time", "level", "caller", "prefix", "msg", "fields":
+		default:
+			if used == nil {
+				used = make(map[string]bool)
+			}
+			used[p.name] = true
+		}
+	}
+
+	vals := make([]string, len(l.template))
+	for i, p := range l.template {
+		if p.name != "" {
+			vals[i] = l.templateValue(t, used, p)
+		}
+	}
+
+	// the whitespace between parts is written once, between written parts
+	var sep string
+	written := false
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if written {
+			l.b.WriteString(sep)
+		}
+		l.b.WriteString(s)
+		sep, written = "", true
+	}
+	for i, p := range l.template {
+		if p.name != "" {
+			write(vals[i])
+			continue
+		}
+		before, space, after := splitLiteral(p.literal, i == 0, i == len(l.template)-1)
+		if i == 0 || vals[i-1] != "" {
+			write(before)
+		}
+		if sep == "" {
+			sep = space
+		}
+		if i == len(l.template)-1 || vals[i+1] != "" {
+			write(after)
+		}
+	}
+	l.b.WriteByte('\n')
+}
+
+// splitLiteral splits the literal text of a template into the text attached
+// to the part before it, up to its first whitespace, the text attached to the
+// part after it, from its last whitespace, and the separator in between.
+// Without whitespace, the text is attached to the part next to it at the
+// start or the end of the template, and is a separator elsewhere.
+func splitLiteral(s string, first, last bool) (before, sep, after string) {
+	i := strings.IndexAny(s, " \t\n")
+	if i < 0 {
+		switch {
+		case first:
+			return "", "", s
+		case last:
+			return s, "", ""
+		}
+		return "", s, ""
+	}
+	j := strings.LastIndexAny(s, " \t\n") + 1
+	return s[:i], s[i:j], s[j:]
+}
+
+// templateValue returns the rendered part p of the line.
+func (l *Logger) templateValue(t *textLine, used map[string]bool, p templatePart) string {
+	var val, style string
+	switch p.name {
+	case "time":
+		val, style = t.time, "gy"
+	case "level":
+		val, style = t.level, "level"
+		if p.width > 0 && p.width < len(val) {
+			val = val[:p.width]
+		}
+	case "caller":
+		val, style = t.caller, "gy"
+	case "prefix":
+		val, style = t.prefix, "gy"
+	case "msg":
+		val = t.msg
+	case "fields":
+		return l.templateFields(t, used, p)
+	default:
+		for _, f := range t.fields {
+			if f.Key == p.name {
+				val = f.Value.(string)
+				break
+			}
+		}
+	}
+	if p.width > 0 {
+		val = padRight(val, p.width)
+	} else if p.width < 0 {
+		val = padLeft(val, -p.width)
+	}
+	if p.style != "" {
+		style = p.style
+	}
+	return l.styleText(t, style, val)
+}
+
+// templateFields returns the fields not referenced by name in the template.
+func (l *Logger) templateFields(t *textLine, used map[string]bool, p templatePart) string {
+	var plain, colored strings.Builder
+	for _, f := range t.fields {
+		if used[f.Key] {
+			continue
+		}
+		if plain.Len() > 0 {
+			plain.WriteByte(' ')
+			colored.WriteByte(' ')
+		}
+		key := f.Key + separator
+		plain.WriteString(key + f.Value.(string))
+		if p.style == "" {
+			key = colorize("gy", key)
+		}
+		colored.WriteString(key + f.Value.(string))
+	}
+	// pad on the uncolored text, escape codes take no room
+	pad := ""
+	if n := abs(p.width) - textWidth(plain.String()); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	val := colored.String()
+	if p.style != "" {
+		val = l.styleText(t, p.style, val)
+	}
+	if p.width < 0 {
+		return pad + val
+	}
+	return val + pad
+}
+
+// styleText colors s with the given template style.
+func (l *Logger) styleText(t *textLine, style, s string) string {
+	if s == "" || strings.TrimSpace(s) == "" {
+		return s
+	}
+	switch style {
+	case "", "none":
+		return s
+	case "level":
+		if t.levelColor == "" {
+			return s
+		}
+		return colorize(t.levelColor, s)
+	default:
+		return colorize(style, s)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
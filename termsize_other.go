@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package lg
+
+import "os"
+
+// fileTermWidth returns 0, the terminal size is not queried on this system.
+func fileTermWidth(f *os.File) int {
+	return 0
+}
@@ -3,7 +3,10 @@ package lg
 import (
 	"fmt"
 	"io"
-	"time"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -38,6 +41,16 @@ func Color(color string) string {
 	}
 }
 
+// isColor reports whether Color knows the given color.
+func isColor(color string) bool {
+	switch color {
+	case "gy", "gray", "gr", "green", "yl", "yellow", "bl", "blue",
+		"rd", "red", "mg", "magenta", "aq", "aqua":
+		return true
+	}
+	return false
+}
+
 func toCapLevel(level string) string {
 	switch level {
 	case "debug":
@@ -55,106 +68,245 @@ func toCapLevel(level string) string {
 	}
 }
 
-func (l *Logger) textFormatter(keyvals ...any) {
-	lenKeyvals := len(keyvals)
-	pubMessage := ""
-	args := make([]any, 0, len(keyvals)/2)
-	defer l.b.Reset()
-	for i := 0; i < lenKeyvals; i += 2 {
-		firstKey := i == 0
-		switch keyvals[i] {
-		case TimestampKey:
-			if t, ok := keyvals[i+1].(time.Time); ok {
-				ts := t.Format(l.timeFormat)
-				pubMessage += "  " + TimestampKey + "=" + ts
-				color := Color("gy") + ts
-				writeSpace(&l.b, firstKey)
-				l.b.WriteString(color)
-				args = append(args, TimestampKey+"=")
-			}
-		case LevelKey:
-			if level, ok := keyvals[i+1].(Level); ok {
-				lvl := level.String()
-				if len(lvl) > 0 {
-					cc := levelColors[lvl]
-					lvl = toCapLevel(lvl)
-					if len(lvl) > 3 {
-						lvl = lvl[:4]
-					}
-					if pubMessage == "" {
-						pubMessage += lvl
-					} else {
-						pubMessage += " " + lvl
-					}
-					color := Color(cc)
-					writeSpace(&l.b, firstKey)
-					l.b.WriteString(color)
-					args = append(args, lvl)
-				}
-			}
-		case CallerKey:
-			if caller, ok := keyvals[i+1].(string); ok {
-				caller = "[" + caller + "]"
-				if pubMessage == "" {
-					pubMessage += caller
-				} else {
-					pubMessage += " " + caller
-				}
-				cc := Color("gy")
-				writeSpace(&l.b, firstKey)
-				l.b.WriteString(cc)
-				args = append(args, caller)
-			}
-		case PrefixKey:
-			if prefix, ok := keyvals[i+1].(string); ok {
-				pubMessage += " " + prefix + ":"
-				cc := Color("gy")
-				writeSpace(&l.b, firstKey)
-				l.b.WriteString(cc)
-				args = append(args, prefix+":")
+// colorize wraps s in the escape codes of the given color.
+func colorize(color, s string) string {
+	return fmt.Sprintf(Color(color), s)
+}
+
+// textLine holds the escaped parts of a text log line.
+type textLine struct {
+	// level is the capitalized level name, empty for NoLevel.
+	level      string
+	levelColor string
+	caller     string
+	prefix     string
+	msg        string
+	time       string
+	// fields values are quoted, ready to be written.
+	fields []Field
+	// blocks are the multi-line values, written under the line.
+	blocks []Field
+	// trees are the nested values rendered as a tree, under the line.
+	trees []Field
+}
+
+func (l *Logger) newTextLine(r *Record) *textLine {
+	t := &textLine{
+		caller: r.Caller,
+		prefix: l.escapeText(r.Prefix),
+		msg:    l.escapeText(r.Message),
+	}
+	if lvl := r.Level.String(); lvl != "" {
+		t.level = toCapLevel(lvl)
+		t.levelColor = levelColors[lvl]
+	}
+	if l.reportTimestamp && !r.Time.IsZero() {
+		t.time = r.Time.Format(l.timeFormat)
+	}
+	for _, f := range r.Fields {
+		if f.Key == "" {
+			continue
+		}
+		val := textValue(f.Value)
+		if l.useTree(f.Value, val) {
+			t.trees = append(t.trees, f)
+			continue
+		}
+		if l.multilineBlock && strings.Contains(val, "\n") {
+			t.blocks = append(t.blocks, Field{f.Key, val})
+			continue
+		}
+		t.fields = append(t.fields, Field{l.quoteKey(f.Key), l.quoteValue(val)})
+	}
+	return t
+}
+
+// shortLevel returns the level truncated to 4 characters.
+func (t *textLine) shortLevel() string {
+	if len(t.level) > 3 {
+		return t.level[:4]
+	}
+	return t.level
+}
+
+func (l *Logger) textFormatter(r *Record) {
+	t := l.newTextLine(r)
+	switch {
+	case l.template != nil:
+		l.writeTemplate(t)
+	case l.layout == LayoutPretty && l.isTerminal:
+		l.writePretty(t)
+	default:
+		l.writePlain(t)
+	}
+
+	for _, f := range t.blocks {
+		l.writeBlock(f.Key, f.Value.(string))
+	}
+	for _, f := range t.trees {
+		l.writeTree(f.Key, f.Value)
+	}
+}
+
+// writePlain writes the default single line layout.
+func (l *Logger) writePlain(t *textLine) {
+	first := true
+	part := func(s string) {
+		writeSpace(&l.b, first)
+		l.b.WriteString(s)
+		first = false
+	}
+	if t.level != "" {
+		part(colorize(t.levelColor, t.shortLevel()))
+	}
+	if t.caller != "" {
+		part(colorize("gy", "["+t.caller+"]"))
+	}
+	if t.prefix != "" {
+		part(colorize("gy", t.prefix+":"))
+	}
+	if t.msg != "" {
+		part(t.msg)
+	}
+	for _, f := range t.fields {
+		part(colorize("gy", f.Key+separator) + f.Value.(string))
+	}
+	if t.time != "" {
+		part(colorize("gy", l.keys.Timestamp+separator) + t.time)
+	}
+	l.b.WriteByte('\n')
+}
+
+// writeBlock writes a multi-line value as an indented block under the line.
+// Every line of the block is prefixed, so it cannot pass for a log line.
+func (l *Logger) writeBlock(key, val string) {
+	key = l.quoteKey(key) + separator
+	l.b.WriteString("  " + colorize("gy", key) + "\n")
+	for _, line := range strings.Split(strings.TrimSuffix(val, "\n"), "\n") {
+		l.b.WriteString("    │ " + l.escapeText(line) + "\n")
+	}
+}
+
+// quoteValue quotes val when it would be ambiguous unquoted.
+func (l *Logger) quoteValue(val string) string {
+	if l.ansiPolicy == ANSIStrip {
+		val = stripANSI(val)
+	}
+	if val == "" {
+		return `""`
+	}
+	if needsQuoting(val) {
+		return strconv.Quote(val)
+	}
+	return val
+}
+
+// quoteKey quotes key when it would be ambiguous unquoted, like a key
+// holding a space or a '='.
+func (l *Logger) quoteKey(key string) string {
+	if l.ansiPolicy == ANSIStrip {
+		key = stripANSI(key)
+	}
+	if needsQuoting(key) {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+func needsQuoting(s string) bool {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return true
 			}
-		case MessageKey:
-			if msg := keyvals[i+1]; msg != nil {
-				if v, ok := msg.(string); ok {
-					pubMessage += " " + v
-					writeSpace(&l.b, firstKey)
-					l.b.WriteString(v)
-				} else {
-					fmt.Println("expect msg to be string, got:", msg)
-					return
-				}
+		}
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeText escapes the control characters of s, including newlines and
+// escape sequences, so s stays on a single line and cannot restyle it.
+func (l *Logger) escapeText(s string) string {
+	if l.ansiPolicy == ANSIStrip {
+		s = stripANSI(s)
+	}
+	clean := true
+	for _, r := range s {
+		if r != '\t' && (unicode.IsControl(r) || r == utf8.RuneError) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return s
+	}
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteRune(r)
+		case r == utf8.RuneError:
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				fmt.Fprintf(&b, `\x%02x`, s[i])
+			} else {
+				b.WriteRune(r)
 			}
-		default:
-			sep := separator
-			key := keyvals[i]
-			if vStr, ok := key.(string); !ok {
-				fmt.Printf("log error: textFormatter, expect key to be string, got %v\n", key)
-				return
+		case unicode.IsControl(r):
+			if r < 0x100 {
+				fmt.Fprintf(&b, `\x%02x`, r)
 			} else {
-				key = vStr + sep
-				cc := " " + Color("gy")
-				val := fmt.Sprintf("%+v", keyvals[i+1])
-				raw := val == ""
-				if raw {
-					val = `""`
-				}
-				if vStr == "" {
-					continue
-				}
-				pubMessage += " " + vStr + "=" + val
-				l.b.WriteString(cc + val)
-				args = append(args, key)
+				fmt.Fprintf(&b, `\u%04x`, r)
 			}
+		default:
+			b.WriteRune(r)
 		}
 	}
-	if saveMem {
-		ss.Add(pubMessage)
+	return b.String()
+}
+
+// stripANSI removes the ANSI escape sequences from s.
+func stripANSI(s string) string {
+	if !strings.ContainsAny(s, "\x1b\u009b") {
+		return s
 	}
-	if usePub && pub != nil {
-		pub.Publish(topicPub, map[string]any{
-			"log": pubMessage,
-		})
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 0x1b && i+1 < len(s) && s[i+1] == '[':
+			// CSI: parameters and intermediates, then a final byte
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+		case c == 0x1b && i+1 < len(s) && s[i+1] == ']':
+			// OSC: terminated by BEL or ST
+			i += 2
+			for i < len(s) && s[i] != 0x07 && !(s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\') {
+				i++
+			}
+			if i < len(s) && s[i] == 0x1b {
+				i++
+			}
+		case c == 0x1b:
+			// two-byte sequence
+			i++
+		case c == 0xc2 && i+1 < len(s) && s[i+1] == 0x9b:
+			// 8-bit CSI
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+		default:
+			b.WriteByte(c)
+		}
 	}
-	_, err := fmt.Fprintf(l.w, l.b.String()+"\n", args...)
-	l.CheckError(err)
+	return b.String()
 }
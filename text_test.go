@@ -0,0 +1,76 @@
+package lg
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// logColors matches the escape codes written by the logger itself.
+var logColors = regexp.MustCompile(`\x1b\[(1;3[0-7]|0)m`)
+
+// uncolored returns the output of the logger without its colors.
+func uncolored(b *bytes.Buffer) string {
+	return logColors.ReplaceAllString(b.String(), "")
+}
+
+func TestTextKeyQuoting(t *testing.T) {
+	var b bytes.Buffer
+	l := NewWithOptions(&b, Options{})
+	l.Info("m", "a b=c", "keyspace", `k"q`, 1, "ok", "v")
+
+	want := `INFO m "a b=c"=keyspace "k\"q"=1 ok=v` + "\n"
+	if got := uncolored(&b); got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+}
+
+// hostile holds a newline, a carriage return, an escape sequence and a C1
+// control sequence introducer.
+const hostile = "a\nINFO forged\r\x1b[31mred\x1b[0m\u009b2J"
+
+func TestTextInjection(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		policy ANSIPolicy
+		want   string
+		value  string
+	}{
+		{"escape", ANSIEscape, `a\nINFO forged\r\x1b[31mred\x1b[0m\x9b2J`, `"a\nINFO forged\r\x1b[31mred\x1b[0m\u009b2J"`},
+		{"strip", ANSIStrip, `a\nINFO forged\rred`, `"a\nINFO forged\rred"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			l := NewWithOptions(&b, Options{ANSIPolicy: tt.policy, Prefix: hostile})
+			l.Info(hostile, "v", hostile)
+			l.SetMultilineBlock(true)
+			l.Info("block", "v", hostile)
+
+			out := uncolored(&b)
+			if strings.ContainsAny(out, "\r\x1b\u009b") {
+				t.Fatalf("control characters reached the output: %q", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			wantLines := []string{
+				"INFO " + tt.want + ": " + tt.want + " v=" + tt.value,
+				"INFO " + tt.want + ": block",
+				"  v=",
+			}
+			if len(lines) < len(wantLines) {
+				t.Fatalf("got lines %q", lines)
+			}
+			for i, want := range wantLines {
+				if lines[i] != want {
+					t.Errorf("line %d:\ngot  %q\nwant %q", i, lines[i], want)
+				}
+			}
+			// the lines of a block are indented, so they cannot pass for records
+			for _, line := range lines[len(wantLines):] {
+				if !strings.HasPrefix(line, "    │ ") {
+					t.Errorf("block line %q is not indented", line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package lg
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const defaultTransportMessage = "http call"
+
+// defaultRedactParams are the query parameters redacted by default.
+var defaultRedactParams = []string{
+	"access_token", "api_key", "apikey", "auth", "client_secret", "key",
+	"password", "secret", "sig", "signature", "token",
+}
+
+// TransportOptions configures NewTransport.
+type TransportOptions struct {
+	// Level returns the level of the record of a response status. The default
+	// is ErrorLevel for 5xx, WarnLevel for 4xx and InfoLevel otherwise. The
+	// calls failing without response are logged at ErrorLevel.
+	Level func(status int) Level
+	// Message is the message of the records. The default is "http call".
+	Message string
+	// RedactParams are the query parameters whose values are redacted, their
+	// case ignored. The default is a list of usual secrets, like "token",
+	// "api_key" and "signature". Passwords of the URLs are always redacted.
+	RedactParams []string
+	// BodyLimit is the number of bytes of the request and response bodies
+	// logged, the response body being read up to it before the call returns.
+	// The default is 0, for no bodies.
+	BodyLimit int
+	// MaxRetries is the number of times the calls failing without response or
+	// with a 502, 503 or 504 status are retried, when they are idempotent and
+	// their body can be sent again. The calls are idempotent when their method
+	// is, like GET, PUT and DELETE but not POST, or when they have an
+	// Idempotency-Key or X-Idempotency-Key header. The default is 0.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled after each
+	// retry. The default is 100ms.
+	RetryBackoff time.Duration
+}
+
+// transport is a http.RoundTripper logging the calls.
+type transport struct {
+	l      *Logger
+	next   http.RoundTripper
+	opts   TransportOptions
+	redact map[string]bool
+}
+
+// NewTransport returns a http.RoundTripper logging the calls made through
+// next, http.DefaultTransport when nil, with logger, the default logger when
+// nil: their method, URL, status, latency and retries.
+//
+// The caller of the records is the code making the call, outside net/http
+// and this package. It is reported for the failed calls whatever the caller
+// reporting of the logger.
+func NewTransport(logger *Logger, next http.RoundTripper, opts ...TransportOptions) http.RoundTripper {
+	if logger == nil {
+		logger = Default()
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &transport{l: logger, next: next}
+	if len(opts) > 0 {
+		t.opts = opts[0]
+	}
+	if t.opts.Level == nil {
+		t.opts.Level = statusLevel
+	}
+	if t.opts.Message == "" {
+		t.opts.Message = defaultTransportMessage
+	}
+	if t.opts.RedactParams == nil {
+		t.opts.RedactParams = defaultRedactParams
+	}
+	if t.opts.RetryBackoff <= 0 {
+		t.opts.RetryBackoff = defaultRetryBackoff
+	}
+	t.redact = make(map[string]bool, len(t.opts.RedactParams))
+	for _, p := range t.opts.RedactParams {
+		t.redact[strings.ToLower(p)] = true
+	}
+	return t
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	keyvals := []any{"method", req.Method, "url", t.redactURL(req.URL)}
+
+	var reqBody []byte
+	if t.opts.BodyLimit > 0 && req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, req, err = t.peekRequestBody(req)
+		if err != nil {
+			// a RoundTripper must close the body, even on errors
+			req.Body.Close() //nolint: errcheck
+			return nil, err
+		}
+	}
+
+	resp, retries, err := t.send(req)
+	keyvals = append(keyvals, "latency", time.Since(start), "retries", retries)
+	if err != nil {
+		keyvals = append(keyvals, "err", err)
+		if reqBody != nil {
+			keyvals = append(keyvals, "request_body", string(reqBody))
+		}
+		t.l.logFrame(ErrorLevel, callerOutside(), true, t.opts.Message, keyvals...)
+		return nil, err
+	}
+
+	level := t.opts.Level(resp.StatusCode)
+	keyvals = append(keyvals, "status", resp.StatusCode)
+	if t.opts.BodyLimit > 0 && t.l.enabled(level) {
+		if reqBody != nil {
+			keyvals = append(keyvals, "request_body", string(reqBody))
+		}
+		var respBody []byte
+		respBody, resp.Body = peekBody(resp.Body, t.opts.BodyLimit)
+		keyvals = append(keyvals, "response_body", string(respBody))
+	}
+	t.l.logFrame(level, callerOutside(), t.l.reportCaller, t.opts.Message, keyvals...)
+	return resp, nil
+}
+
+// send sends req, retrying it as configured.
+func (t *transport) send(req *http.Request) (*http.Response, int, error) {
+	backoff := t.opts.RetryBackoff
+	maxRetries := t.opts.MaxRetries
+	if !idempotent(req) {
+		maxRetries = 0
+	}
+	for retries := 0; ; retries++ {
+		resp, err := t.next.RoundTrip(req)
+		if retries >= maxRetries || !shouldRetry(resp, err) {
+			return resp, retries, err
+		}
+		next, ok := replay(req)
+		if !ok {
+			return resp, retries, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body) //nolint: errcheck
+			resp.Body.Close()              //nolint: errcheck
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, retries, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		req = next
+	}
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent reports whether req can be sent more than once: its method is
+// idempotent (RFC 9110), or it has an idempotency key, as for net/http.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+// replay returns a copy of req to send again, if its body can be sent again.
+func replay(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, true
+}
+
+// peekRequestBody returns the first bytes of the body of req, and a copy of
+// req sending the whole body.
+func (t *transport) peekRequestBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, req, err
+		}
+		defer body.Close()
+		b, err := io.ReadAll(io.LimitReader(body, int64(t.opts.BodyLimit)))
+		return b, req, err
+	}
+	// a RoundTripper must not modify the request
+	b, body := peekBody(req.Body, t.opts.BodyLimit)
+	next := req.Clone(req.Context())
+	next.Body = body
+	return b, next, nil
+}
+
+// peekBody returns the first limit bytes of body, and a body reading them
+// then the rest of body.
+func peekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	b, _ := io.ReadAll(io.LimitReader(body, int64(limit)))
+	return b, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), body), body}
+}
+
+// redactURL returns u without its password and the values of its secret
+// query parameters.
+func (t *transport) redactURL(u *url.URL) string {
+	if u.RawQuery != "" {
+		q := u.Query()
+		redacted := false
+		for name, values := range q {
+			if t.redact[strings.ToLower(name)] {
+				for i := range values {
+					values[i] = "REDACTED"
+				}
+				redacted = true
+			}
+		}
+		if redacted {
+			c := *u
+			c.RawQuery = q.Encode()
+			u = &c
+		}
+	}
+	return u.Redacted()
+}
+
+// CloseIdleConnections closes the idle connections of the underlying
+// transport, when it supports it.
+func (t *transport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// callerOutside returns the first frame of the goroutine outside net/http,
+// this package and the runtime.
+func callerOutside() runtime.Frame {
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "net/http.") &&
+			!strings.HasPrefix(f.Function, lgPackage+".") &&
+			!strings.HasPrefix(f.Function, "runtime.") {
+			return f
+		}
+		if !more {
+			return runtime.Frame{}
+		}
+	}
+}
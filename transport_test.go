@@ -0,0 +1,175 @@
+package lg
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTransportTestClient(opts TransportOptions) (*http.Client, *syncBuffer) {
+	out := &syncBuffer{}
+	l := NewWithOptions(out, Options{Formatter: JSONFormatter})
+	return &http.Client{Transport: NewTransport(l, nil, opts)}, out
+}
+
+func TestTransportRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	client, out := newTransportTestClient(TransportOptions{RedactParams: []string{"token", "Sig"}})
+
+	u := strings.Replace(srv.URL, "http://", "http://user:secret@", 1) + "/path?q=go&TOKEN=abc&sig=def"
+	resp, err := client.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	records := out.records(t)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	got := records[0]["url"].(string)
+	want := strings.Replace(srv.URL, "http://", "http://user:xxxxx@", 1) + "/path?TOKEN=REDACTED&q=go&sig=REDACTED"
+	if got != want {
+		t.Errorf("url = %s, want %s", got, want)
+	}
+}
+
+// flakyServer fails the first calls with a 503 status, and records the
+// bodies of the calls.
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *int32, chan string) {
+	var calls int32
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, bodies
+}
+
+func TestTransportRetries(t *testing.T) {
+	opts := TransportOptions{MaxRetries: 3, RetryBackoff: time.Millisecond}
+	for _, tt := range []struct {
+		name    string
+		method  string
+		header  string
+		body    io.Reader
+		calls   int32
+		status  int
+		retries float64
+	}{
+		{"get", http.MethodGet, "", nil, 3, http.StatusOK, 2},
+		{"put with body", http.MethodPut, "", strings.NewReader("data"), 3, http.StatusOK, 2},
+		{"post", http.MethodPost, "", strings.NewReader("data"), 1, http.StatusServiceUnavailable, 0},
+		{"post with key", http.MethodPost, "Idempotency-Key", strings.NewReader("data"), 3, http.StatusOK, 2},
+		{"put without GetBody", http.MethodPut, "", io.MultiReader(strings.NewReader("data")), 1, http.StatusServiceUnavailable, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls, bodies := flakyServer(t, 2)
+			client, out := newTransportTestClient(opts)
+			req, err := http.NewRequest(tt.method, srv.URL, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, "1")
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if *calls != tt.calls || resp.StatusCode != tt.status {
+				t.Fatalf("got %d calls and status %d, want %d and %d", *calls, resp.StatusCode, tt.calls, tt.status)
+			}
+			if tt.body != nil {
+				for i := int32(0); i < tt.calls; i++ {
+					if b := <-bodies; b != "data" {
+						t.Errorf("call %d sent body %q", i, b)
+					}
+				}
+			}
+			records := out.records(t)
+			if len(records) != 1 || records[0]["retries"] != tt.retries {
+				t.Fatalf("got records %v, want one with %v retries", records, tt.retries)
+			}
+		})
+	}
+}
+
+func TestTransportBodies(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+		io.WriteString(w, "response body") //nolint: errcheck
+	}))
+	defer srv.Close()
+	client, out := newTransportTestClient(TransportOptions{BodyLimit: 5})
+
+	for _, body := range []io.Reader{
+		strings.NewReader("hello world"),
+		// without GetBody, the body is read by the transport
+		io.MultiReader(strings.NewReader("hello world")),
+	} {
+		resp, err := client.Post(srv.URL, "text/plain", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "response body" || received != "hello world" {
+			t.Errorf("the server received %q and the client %q", received, b)
+		}
+	}
+
+	records := out.records(t)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	for _, r := range records {
+		if r["request_body"] != "hello" || r["response_body"] != "respo" {
+			t.Errorf("got bodies %q and %q, want their first 5 bytes", r["request_body"], r["response_body"])
+		}
+	}
+}
+
+// closeRecorder records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTransportBodyError(t *testing.T) {
+	errGetBody := errors.New("no body")
+	body := &closeRecorder{Reader: strings.NewReader("hello")}
+	req := httptest.NewRequest("POST", "http://example.com", body)
+	req.GetBody = func() (io.ReadCloser, error) { return nil, errGetBody }
+
+	l := NewWithOptions(&syncBuffer{}, Options{})
+	_, err := NewTransport(l, nil, TransportOptions{BodyLimit: 5}).RoundTrip(req)
+	if !errors.Is(err, errGetBody) {
+		t.Fatalf("got %v, want %v", err, errGetBody)
+	}
+	if !body.closed {
+		t.Error("the request body was not closed")
+	}
+}
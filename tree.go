@@ -0,0 +1,109 @@
+package lg
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultTreeThreshold = 80
+	defaultTreeMaxDepth  = 6
+	defaultTreeMaxItems  = 20
+)
+
+// TreeOptions configures the rendering of nested maps, slices and structs
+// as an indented tree under the line, in the text formatter.
+type TreeOptions struct {
+	// Enabled turns the tree rendering on. It only applies when the output
+	// is a terminal. The default is false.
+	Enabled bool
+	// Threshold is the length a value must exceed on a single line to be
+	// rendered as a tree. The default is 80.
+	Threshold int
+	// MaxDepth is the number of nested levels rendered. The default is 6.
+	MaxDepth int
+	// MaxItems is the number of items rendered per collection. The default is 20.
+	MaxItems int
+}
+
+// useTree reports whether the encoded value v, rendered as s on a single line,
+// should be rendered as a tree.
+func (l *Logger) useTree(v any, s string) bool {
+	if !l.tree.Enabled || !l.isTerminal {
+		return false
+	}
+	threshold := l.tree.Threshold
+	if threshold <= 0 {
+		threshold = defaultTreeThreshold
+	}
+	return isNested(v) && textWidth(s) > threshold
+}
+
+func isNested(v any) bool {
+	switch v := v.(type) {
+	case []any:
+		return len(v) > 0
+	case []Field:
+		return len(v) > 0
+	}
+	return false
+}
+
+// writeTree writes the encoded value v as a tree under the line.
+func (l *Logger) writeTree(key string, v any) {
+	opts := l.tree
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultTreeMaxDepth
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = defaultTreeMaxItems
+	}
+	key = l.quoteKey(key) + separator
+
+	var b strings.Builder
+	b.WriteString("  " + colorize("gy", key) + "\n")
+	l.renderTree(&b, opts, v, "    ", 1)
+	l.b.WriteString(b.String())
+}
+
+func (l *Logger) renderTree(b *strings.Builder, opts TreeOptions, v any, indent string, depth int) {
+	item := func(label string, val any) {
+		b.WriteString(indent + label)
+		switch {
+		case !isNested(val):
+			b.WriteString(" " + l.quoteValue(textValue(val)) + "\n")
+		case depth >= opts.MaxDepth:
+			if _, ok := val.([]any); ok {
+				b.WriteString(" [...]\n")
+			} else {
+				b.WriteString(" {...}\n")
+			}
+		default:
+			b.WriteByte('\n')
+			l.renderTree(b, opts, val, indent+"  ", depth+1)
+		}
+	}
+
+	n := 0
+	switch v := v.(type) {
+	case []Field:
+		n = len(v)
+		for i, f := range v {
+			if i == opts.MaxItems {
+				break
+			}
+			item(colorize("aq", l.escapeText(f.Key)+":"), f.Value)
+		}
+	case []any:
+		n = len(v)
+		for i, val := range v {
+			if i == opts.MaxItems {
+				break
+			}
+			item(colorize("gy", "-"), val)
+		}
+	}
+	if n > opts.MaxItems {
+		b.WriteString(indent + colorize("gy", "... "+strconv.Itoa(n-opts.MaxItems)+" more") + "\n")
+	}
+}
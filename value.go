@@ -0,0 +1,333 @@
+package lg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxValueDepth is how deep nested values are walked before being elided.
+const maxValueDepth = 16
+
+// cycleMarker replaces a value referencing one of its parents.
+const cycleMarker = "<cycle>"
+
+// LogMarshaler is implemented by types that control their own representation
+// in log output. The returned value is encoded in place of the original one.
+type LogMarshaler interface {
+	MarshalLog() any
+}
+
+// DurationFormat is how time.Duration values are encoded.
+type DurationFormat uint8
+
+const (
+	// DurationString encodes durations as strings, like "1.5s".
+	DurationString DurationFormat = iota
+	// DurationMillis encodes durations as a number of milliseconds.
+	DurationMillis
+)
+
+// BytesFormat is how byte slices are encoded.
+type BytesFormat uint8
+
+const (
+	// BytesHex encodes byte slices as lowercase hexadecimal strings.
+	BytesHex BytesFormat = iota
+	// BytesBase64 encodes byte slices as standard base64 strings.
+	BytesBase64
+	// BytesUTF8 encodes byte slices as strings, as is.
+	BytesUTF8
+)
+
+// encodeValue converts v to one of nil, string, bool, int64, uint64,
+// float64, json.RawMessage, []any or []Field, applying the logger settings.
+func (l *Logger) encodeValue(v any) any {
+	return l.normalize(v, 0, nil)
+}
+
+// normalize encodes v, path holds the addresses of the pointers, maps and
+// slices being walked, to detect cycles.
+func (l *Logger) normalize(v any, depth int, path []uintptr) any {
+	// fast path for the common types
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case bool:
+		return v
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return v
+	case time.Time:
+		return v.Format(l.timeFormat)
+	case time.Duration:
+		return l.encodeDuration(v)
+	case []byte:
+		if v == nil {
+			return nil
+		}
+		return l.encodeBytes(v)
+	}
+
+	if depth >= maxValueDepth {
+		return "..."
+	}
+
+	rv := reflect.ValueOf(v)
+	for {
+		kind := rv.Kind()
+		isRef := kind == reflect.Pointer || kind == reflect.Interface
+		// nil pointers and interfaces must not reach the method calls below
+		if isRef && rv.IsNil() {
+			return nil
+		}
+		if kind == reflect.Pointer {
+			if inPath(path, rv.Pointer()) {
+				return cycleMarker
+			}
+			path = append(path, rv.Pointer())
+		}
+		if rv.CanInterface() {
+			if out, ok := l.normalizeKnown(rv.Interface(), depth, path); ok {
+				return out
+			}
+		}
+		if !isRef {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32:
+		// go through the shortest 32-bit representation to avoid noise digits
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+		return f
+	case reflect.Float64:
+		return rv.Float()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return l.encodeBytes(rv.Bytes())
+		}
+		if rv.Len() > 0 {
+			if inPath(path, rv.Pointer()) {
+				return cycleMarker
+			}
+			path = append(path, rv.Pointer())
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = l.normalize(valueInterface(rv.Index(i)), depth+1, path)
+		}
+		return items
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		if inPath(path, rv.Pointer()) {
+			return cycleMarker
+		}
+		path = append(path, rv.Pointer())
+		fields := make([]Field, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			fields = append(fields, Field{
+				Key:   mapKey(valueInterface(iter.Key())),
+				Value: l.normalize(valueInterface(iter.Value()), depth+1, path),
+			})
+		}
+		// map iteration order is random
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		return fields
+	case reflect.Struct:
+		return l.normalizeStruct(rv, depth, path)
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
+
+// normalizeKnown handles the types with a dedicated encoding. The panics of
+// their methods are encoded as the value, as fmt does.
+func (l *Logger) normalizeKnown(v any, depth int, path []uintptr) (out any, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, ok = fmt.Sprintf("!PANIC: %v", r), true
+		}
+	}()
+	switch v := v.(type) {
+	case LogMarshaler:
+		return l.normalize(v.MarshalLog(), depth+1, path), true
+	case time.Time:
+		return v.Format(l.timeFormat), true
+	case time.Duration:
+		return l.encodeDuration(v), true
+	case error:
+		return l.encodeError(v), true
+	case fmt.Stringer:
+		return v.String(), true
+	case json.Marshaler:
+		b, err := v.MarshalJSON()
+		if err == nil {
+			var buf bytes.Buffer
+			if err = json.Compact(&buf, b); err == nil {
+				return json.RawMessage(buf.Bytes()), true
+			}
+		}
+		return fmt.Sprintf("!ERROR: %v", err), true
+	}
+	return nil, false
+}
+
+// mapKey returns the key k of a map as a string. Like values, nil pointer
+// keys are not dereferenced, and the panics of their methods are encoded as
+// the key, as fmt does.
+func mapKey(k any) (key string) {
+	defer func() {
+		if r := recover(); r != nil {
+			key = fmt.Sprintf("!PANIC: %v", r)
+		}
+	}()
+	if rv := reflect.ValueOf(k); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return "<nil>"
+	}
+	return fieldKey(k)
+}
+
+// normalizeStruct converts the exported fields of a struct, honouring
+// the name, "-" and omitempty options of json tags.
+func (l *Logger) normalizeStruct(rv reflect.Value, depth int, path []uintptr) []Field {
+	t := rv.Type()
+	fields := make([]Field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			tagName, opts, _ := strings.Cut(tag, ",")
+			if tagName != "" {
+				name = tagName
+			}
+			omitEmpty = strings.Contains(","+opts+",", ",omitempty,")
+		}
+		fv := rv.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, Field{Key: name, Value: l.normalize(valueInterface(fv), depth+1, path)})
+	}
+	return fields
+}
+
+func (l *Logger) encodeDuration(d time.Duration) any {
+	switch l.durationFormat {
+	case DurationMillis:
+		return float64(d) / float64(time.Millisecond)
+	default:
+		return d.String()
+	}
+}
+
+func (l *Logger) encodeBytes(b []byte) string {
+	switch l.bytesFormat {
+	case BytesBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case BytesUTF8:
+		return string(b)
+	default:
+		return hex.EncodeToString(b)
+	}
+}
+
+func inPath(path []uintptr, p uintptr) bool {
+	for _, q := range path {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// valueInterface returns the value held by rv, or nil for an invalid value.
+func valueInterface(rv reflect.Value) any {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// textValue renders an encoded value for the text formatter.
+func textValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case json.RawMessage:
+		return string(v)
+	case []any:
+		var b strings.Builder
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(textValue(item))
+		}
+		b.WriteByte(']')
+		return b.String()
+	case []Field:
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(f.Key)
+			b.WriteByte(':')
+			b.WriteString(textValue(f.Value))
+		}
+		b.WriteByte('}')
+		return b.String()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
@@ -0,0 +1,42 @@
+package lg
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string { panic("boom") }
+
+type derefKey struct{ name string }
+
+func (k *derefKey) String() string { return k.name }
+
+type panickingKey struct{}
+
+func (panickingKey) String() string { panic("bad key") }
+
+func TestEncodeValuePanics(t *testing.T) {
+	l := NewWithOptions(io.Discard, Options{})
+	if got := l.encodeValue(panickingStringer{}); got != "!PANIC: boom" {
+		t.Errorf("got %v, want the panic", got)
+	}
+	var nilKey *derefKey
+	got := l.encodeValue(map[any]int{nilKey: 1, &derefKey{"a"}: 2, panickingKey{}: 3})
+	want := []Field{{"!PANIC: bad key", int64(3)}, {"<nil>", int64(1)}, {"a", int64(2)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLogMapNilPointerKey(t *testing.T) {
+	var b bytes.Buffer
+	l := NewWithOptions(&b, Options{Formatter: JSONFormatter})
+	l.Info("x", "m", map[*derefKey]int{nil: 1})
+	if want := `{"level":"info","msg":"x","m":{"<nil>":1}}` + "\n"; b.String() != want {
+		t.Fatalf("got %s, want %s", b.String(), want)
+	}
+}
@@ -0,0 +1,188 @@
+package lg
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// viewerReplay is the number of kept records the viewer loads on open.
+const viewerReplay = 1000
+
+// ViewerHandler returns a handler serving a self-contained log viewer page
+// for the memory store of the logger, see SaveToMem.
+//
+// The page streams the records from the same handler, with the "stream"
+// query parameter and the parameters of TailHandler. It filters them by
+// level, prefix and text, can pause the stream, expands their fields and
+// exports the records shown as JSON lines.
+func (l *Logger) ViewerHandler() http.Handler {
+	tail := l.TailHandler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("stream") {
+			tail.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		h.Set("Content-Length", strconv.Itoa(len(viewerPage)))
+		h.Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'")
+		w.Write([]byte(viewerPage)) //nolint: errcheck
+	})
+}
+
+// ViewerHandler returns a handler serving a log viewer page for the
+// default logger.
+func ViewerHandler() http.Handler {
+	return Default().ViewerHandler()
+}
+
+var viewerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Logs</title>
+<style>
+body { margin: 0; font: 13px/1.4 ui-monospace, Menlo, Consolas, monospace; background: #1e1f22; color: #d4d4d4; }
+header { position: sticky; top: 0; display: flex; gap: 8px; align-items: center; padding: 8px; background: #2b2d30; border-bottom: 1px solid #3c3f41; }
+header input, header select, header button { font: inherit; background: #1e1f22; color: inherit; border: 1px solid #4e5157; border-radius: 3px; padding: 3px 6px; }
+header button { cursor: pointer; }
+#status { margin-left: auto; color: #8c8c8c; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: 2px 8px; vertical-align: top; border-bottom: 1px solid #2b2d30; white-space: pre-wrap; word-break: break-word; }
+td.time, td.caller { color: #8c8c8c; white-space: nowrap; }
+td.level { font-weight: bold; white-space: nowrap; }
+.debug { color: #589df6; } .info { color: #5fb865; } .warn { color: #d9a343; } .error { color: #e0605b; } .fatal { color: #c678dd; }
+.prefix { color: #8c8c8c; }
+details summary { cursor: pointer; color: #8c8c8c; }
+details pre { margin: 4px 0; color: #d4d4d4; }
+</style>
+</head>
+<body>
+<header>
+<select id="level">
+<option value="-4">debug</option>
+<option value="0">info</option>
+<option value="4">warn</option>
+<option value="8">error</option>
+<option value="12">fatal</option>
+</select>
+<input id="prefix" placeholder="prefix">
+<input id="text" placeholder="search" size="30">
+<button id="pause">Pause</button>
+<button id="export">Export</button>
+<button id="clear">Clear</button>
+<span id="status">connecting</span>
+</header>
+<table><tbody id="rows"></tbody></table>
+<script>
+(function () {
+  "use strict";
+  var levels = { debug: -4, info: 0, warn: 4, error: 8, fatal: 12 };
+  var maxRecords = 5000;
+  var records = [], pending = [], paused = false;
+  var $ = function (id) { return document.getElementById(id); };
+  var rows = $("rows");
+
+  function text(r) {
+    return [r.level, r.caller, r.prefix, r.msg, JSON.stringify(r.fields)].join(" ").toLowerCase();
+  }
+  function matches(r) {
+    var lvl = r.level in levels ? levels[r.level] : Infinity;
+    if (lvl < Number($("level").value)) return false;
+    var prefix = $("prefix").value;
+    if (prefix && r.prefix !== prefix) return false;
+    var q = $("text").value.toLowerCase();
+    return !q || text(r).indexOf(q) >= 0;
+  }
+  function cell(tr, cls, value) {
+    var td = document.createElement("td");
+    td.className = cls;
+    td.textContent = value;
+    tr.appendChild(td);
+    return td;
+  }
+  function row(r) {
+    var tr = document.createElement("tr");
+    cell(tr, "time", r.time.replace("T", " ").replace(/\.\d+/, ""));
+    cell(tr, "level " + r.level, r.level.toUpperCase());
+    cell(tr, "caller", r.caller);
+    var msg = cell(tr, "msg", "");
+    if (r.prefix) {
+      var p = document.createElement("span");
+      p.className = "prefix";
+      p.textContent = r.prefix + ": ";
+      msg.appendChild(p);
+    }
+    msg.appendChild(document.createTextNode(r.msg));
+    var keys = Object.keys(r.fields || {});
+    if (keys.length) {
+      var d = document.createElement("details");
+      var s = document.createElement("summary");
+      s.textContent = keys.join(", ");
+      var pre = document.createElement("pre");
+      pre.textContent = JSON.stringify(r.fields, null, 2);
+      d.appendChild(s);
+      d.appendChild(pre);
+      msg.appendChild(d);
+    }
+    return tr;
+  }
+  function add(r) {
+    records.push(r);
+    if (records.length > maxRecords) {
+      var old = records.shift();
+      if (rows.lastChild && rows.lastChild.record === old) rows.removeChild(rows.lastChild);
+    }
+    if (matches(r)) {
+      var tr = row(r);
+      tr.record = r;
+      rows.insertBefore(tr, rows.firstChild);
+    }
+  }
+  function render() {
+    rows.textContent = "";
+    var frag = document.createDocumentFragment();
+    for (var i = records.length - 1; i >= 0; i--) {
+      if (!matches(records[i])) continue;
+      var tr = row(records[i]);
+      tr.record = records[i];
+      frag.appendChild(tr);
+    }
+    rows.appendChild(frag);
+  }
+  function shown() {
+    return records.filter(matches);
+  }
+
+  ["level", "prefix", "text"].forEach(function (id) { $(id).addEventListener("input", render); });
+  $("pause").addEventListener("click", function () {
+    paused = !paused;
+    this.textContent = paused ? "Resume" : "Pause";
+    if (!paused) { pending.forEach(add); pending = []; }
+    $("status").textContent = paused ? "paused" : "live";
+  });
+  $("clear").addEventListener("click", function () { records = []; pending = []; render(); });
+  $("export").addEventListener("click", function () {
+    var lines = shown().map(function (r) { return JSON.stringify(r); }).join("\n");
+    var a = document.createElement("a");
+    a.href = URL.createObjectURL(new Blob([lines + "\n"], { type: "application/x-ndjson" }));
+    a.download = "logs-" + new Date().toISOString() + ".jsonl";
+    a.click();
+    URL.revokeObjectURL(a.href);
+  });
+
+  var es = new EventSource(location.pathname + "?stream&n=` + strconv.Itoa(viewerReplay) + `");
+  es.onopen = function () { $("status").textContent = paused ? "paused" : "live"; };
+  es.onerror = function () { $("status").textContent = "reconnecting"; records = []; pending = []; render(); };
+  es.onmessage = function (e) {
+    var r = JSON.parse(e.data);
+    if (!paused) return add(r);
+    pending.push(r);
+    if (pending.length > maxRecords) pending.shift();
+  };
+})();
+</script>
+</body>
+</html>
+`